@@ -0,0 +1,189 @@
+package mysqldriver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Authentication plugins
+// (see https://dev.mysql.com/doc/internals/en/authentication-method.html)
+const (
+	nativePasswordAuth      = "mysql_native_password"
+	cachingSHA2PasswordAuth = "caching_sha2_password"
+	sha256PasswordAuth      = "sha256_password"
+)
+
+// Data exchanged in AuthMoreData packets
+const (
+	cachingSHA2RequestPublicKey byte = 0x02
+	cachingSHA2FastAuthSuccess  byte = 0x03
+	cachingSHA2PerformFullAuth  byte = 0x04
+	sha256RequestPublicKey      byte = 0x01
+)
+
+var errMalformedPublicKey = errors.New("mysqldriver: malformed public key of the server")
+
+// errPublicKeyRetrieval is returned when the password must be encrypted
+// with public key of the server, but the key can't be requested safely
+var errPublicKeyRetrieval = errors.New("mysqldriver: public key of the server can't be requested " +
+	"without TLS unless it's allowed (see Config.AllowPublicKeyRetrieval)")
+
+// authSecurity defines how the password may be sent to the server
+type authSecurity struct {
+	secure         bool // connection is encrypted or local, so the password is sent in clear text
+	allowPublicKey bool // public key of the server may be requested over insecure connection
+}
+
+// publicKeyRequest returns the request of the public key of the server
+// if it's allowed
+func (s authSecurity) publicKeyRequest(request byte) ([]byte, error) {
+	if !s.allowPublicKey {
+		return nil, errPublicKeyRetrieval
+	}
+	return []byte{request}, nil
+}
+
+// authResponse computes data sent to the server
+// to authenticate the user with the given plugin
+func authResponse(plugin string, nonce []byte, password string, security authSecurity) ([]byte, error) {
+	switch plugin {
+	case nativePasswordAuth, "":
+		return scrambleNativePassword(nonce, password), nil
+	case cachingSHA2PasswordAuth:
+		return scrambleCachingSHA2Password(nonce, password), nil
+	case sha256PasswordAuth:
+		switch {
+		case password == "":
+			return []byte{0}, nil
+		case security.secure:
+			return cleartextPassword(password), nil
+		}
+		return security.publicKeyRequest(sha256RequestPublicKey)
+	}
+	return nil, fmt.Errorf("mysqldriver: unsupported authentication plugin %q", plugin)
+}
+
+// authMoreResponse computes reply to AuthMoreData packet.
+// It returns false when server doesn't expect the reply.
+func authMoreResponse(plugin string, data, nonce []byte, password string, security authSecurity) ([]byte, bool, error) {
+	switch plugin {
+	case cachingSHA2PasswordAuth:
+		if len(data) == 1 {
+			switch data[0] {
+			case cachingSHA2FastAuthSuccess:
+				return nil, false, nil
+			case cachingSHA2PerformFullAuth:
+				if security.secure {
+					return cleartextPassword(password), true, nil
+				}
+				request, err := security.publicKeyRequest(cachingSHA2RequestPublicKey)
+				return request, true, err
+			}
+			break
+		}
+		// the rest is public key of the server
+		encrypted, err := encryptPassword(data, nonce, password)
+		return encrypted, true, err
+	case sha256PasswordAuth:
+		encrypted, err := encryptPassword(data, nonce, password)
+		return encrypted, true, err
+	}
+	return nil, false, fmt.Errorf("mysqldriver: unexpected authentication data for plugin %q", plugin)
+}
+
+// scrambleNativePassword computes
+// SHA1(password) XOR SHA1(nonce + SHA1(SHA1(password)))
+func scrambleNativePassword(nonce []byte, password string) []byte {
+	if password == "" {
+		return nil
+	}
+
+	hash := sha1.New()
+	hash.Write([]byte(password))
+	stage1 := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write(stage1)
+	stage2 := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write(nonce)
+	hash.Write(stage2)
+	scramble := hash.Sum(nil)
+
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	return scramble
+}
+
+// scrambleCachingSHA2Password computes
+// SHA256(password) XOR SHA256(SHA256(SHA256(password)) + nonce)
+func scrambleCachingSHA2Password(nonce []byte, password string) []byte {
+	if password == "" {
+		return nil
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(password))
+	stage1 := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write(stage1)
+	stage2 := hash.Sum(nil)
+
+	hash.Reset()
+	hash.Write(stage2)
+	hash.Write(nonce)
+	scramble := hash.Sum(nil)
+
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	return scramble
+}
+
+// cleartextPassword is sent over secure connections only
+func cleartextPassword(password string) []byte {
+	return append([]byte(password), 0)
+}
+
+// encryptPassword encrypts the password XORed with the nonce
+// using RSA public key of the server in PEM format
+func encryptPassword(pemKey, nonce []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errMalformedPublicKey
+	}
+
+	var key *rsa.PublicKey
+	if block.Type == "RSA PUBLIC KEY" {
+		var err error
+		if key, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	} else {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if key, ok = pub.(*rsa.PublicKey); !ok {
+			return nil, errMalformedPublicKey
+		}
+	}
+
+	plain := cleartextPassword(password)
+	if len(nonce) > 0 {
+		for i := range plain {
+			plain[i] ^= nonce[i%len(nonce)]
+		}
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, key, plain, nil)
+}
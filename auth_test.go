@@ -0,0 +1,186 @@
+package mysqldriver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrambleNativePassword(t *testing.T) {
+	scramble := scrambleNativePassword([]byte("abcdefghijklmnopqrst"), "secret")
+	assert.Equal(t, hex.EncodeToString(scramble), "8817c50fa779daef010ee7577825b0847df9842e")
+	assert.Nil(t, scrambleNativePassword([]byte("abcdefghijklmnopqrst"), ""))
+}
+
+func TestScrambleCachingSHA2Password(t *testing.T) {
+	scramble := scrambleCachingSHA2Password([]byte("abcdefghijklmnopqrst"), "secret")
+	assert.Equal(t, hex.EncodeToString(scramble), "c76e2898612a4cf042c77fa8c4702c4c64c0c2c557c53c4d75595aaa6abae809")
+	assert.Nil(t, scrambleCachingSHA2Password([]byte("abcdefghijklmnopqrst"), ""))
+}
+
+func TestAuthResponseUnsupportedPlugin(t *testing.T) {
+	_, err := authResponse("dialog", nil, "secret", authSecurity{})
+	assert.EqualError(t, err, `mysqldriver: unsupported authentication plugin "dialog"`)
+}
+
+func TestEncryptPasswordMalformedKey(t *testing.T) {
+	_, err := encryptPassword([]byte("not a key"), nil, "secret")
+	assert.Equal(t, err, errMalformedPublicKey)
+}
+
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return key
+}
+
+// publicKeyConn connects to the server over plain TCP
+// allowing public key of the server to be requested
+func publicKeyConn(server *fakeServer, password string) (*Conn, error) {
+	return NewConnFromConfig(context.Background(), &Config{
+		User:                    "root",
+		Password:                password,
+		Net:                     "tcp",
+		Addr:                    server.addr(),
+		DBName:                  "test",
+		AllowPublicKeyRetrieval: true,
+	})
+}
+
+func TestCachingSHA2PasswordFastAuth(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{password: "secret", plugin: cachingSHA2PasswordAuth})
+	defer server.close()
+
+	conn, err := NewConn("root", "secret", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
+
+func TestCachingSHA2PasswordFullAuthRSA(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{
+		password: "secret",
+		plugin:   cachingSHA2PasswordAuth,
+		fullAuth: true,
+		key:      rsaKey(t),
+	})
+	defer server.close()
+
+	conn, err := publicKeyConn(server, "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
+
+func TestCachingSHA2PasswordFullAuthRSANotAllowed(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{
+		password: "secret",
+		plugin:   cachingSHA2PasswordAuth,
+		fullAuth: true,
+		key:      rsaKey(t),
+	})
+	defer server.close()
+
+	conn, err := NewConn("root", "secret", "tcp", server.addr(), "test", time.Duration(0))
+	assert.Equal(t, err, errPublicKeyRetrieval)
+	assert.False(t, conn.valid)
+}
+
+func TestCachingSHA2PasswordFullAuthTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	server := startFakeServer(t, &fakeServer{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		password:  "secret",
+		plugin:    cachingSHA2PasswordAuth,
+		fullAuth:  true,
+	})
+	defer server.close()
+
+	conn, err := NewConnTLS("root", "secret", "tcp", server.addr(), "test", time.Duration(0), &tls.Config{RootCAs: pool})
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
+
+func TestCachingSHA2PasswordWrongPassword(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{
+		password: "secret",
+		plugin:   cachingSHA2PasswordAuth,
+		fullAuth: true,
+		key:      rsaKey(t),
+	})
+	defer server.close()
+
+	conn, err := publicKeyConn(server, "wrong")
+	errPkt, ok := err.(mysqlproto.ERRPacket)
+	assert.True(t, ok)
+	assert.Equal(t, errPkt.ErrorCode, uint16(1045))
+	assert.False(t, conn.valid)
+}
+
+func TestSHA256PasswordRSA(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{password: "secret", plugin: sha256PasswordAuth, key: rsaKey(t)})
+	defer server.close()
+
+	conn, err := publicKeyConn(server, "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
+
+func TestSHA256PasswordRSANotAllowed(t *testing.T) {
+	// account of the user is authenticated by sha256_password,
+	// but it isn't the default plugin of the server
+	server := startFakeServer(t, &fakeServer{password: "secret", switchTo: sha256PasswordAuth, key: rsaKey(t)})
+	defer server.close()
+
+	conn, err := NewConn("root", "secret", "tcp", server.addr(), "test", time.Duration(0))
+	assert.Equal(t, err, errPublicKeyRetrieval)
+	assert.False(t, conn.valid)
+}
+
+func TestSHA256PasswordTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	server := startFakeServer(t, &fakeServer{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		password:  "secret",
+		plugin:    sha256PasswordAuth,
+	})
+	defer server.close()
+
+	conn, err := NewConnTLS("root", "secret", "tcp", server.addr(), "test", time.Duration(0), &tls.Config{RootCAs: pool})
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
+
+func TestAuthSwitch(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{password: "secret", switchTo: cachingSHA2PasswordAuth})
+	defer server.close()
+
+	conn, err := NewConn("root", "secret", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
+
+func TestAuthSwitchToNativePassword(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{
+		password: "secret",
+		plugin:   cachingSHA2PasswordAuth,
+		switchTo: nativePasswordAuth,
+	})
+	defer server.close()
+
+	conn, err := NewConn("root", "secret", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+}
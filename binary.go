@@ -0,0 +1,359 @@
+package mysqldriver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// appendBinaryParams appends NULL bitmap, types and values
+// of statement parameters encoded with the binary protocol
+// (see https://dev.mysql.com/doc/internals/en/com-stmt-execute.html)
+func appendBinaryParams(buf []byte, args []interface{}) ([]byte, error) {
+	if len(args) == 0 {
+		return buf, nil
+	}
+
+	nullOffset := len(buf)
+	for i := 0; i < (len(args)+7)/8; i++ {
+		buf = append(buf, 0)
+	}
+	buf = append(buf, 1) // new-params-bound flag
+
+	typesOffset := len(buf)
+	for range args {
+		buf = append(buf, 0, 0)
+	}
+
+	for i, arg := range args {
+		var fieldType ColumnType
+		var unsigned bool
+
+		switch v := arg.(type) {
+		case nil:
+			buf[nullOffset+i/8] |= 1 << uint(i%8)
+			fieldType = TypeNULL
+		case int:
+			fieldType = TypeLongLong
+			buf = appendUint64(buf, uint64(v))
+		case int8:
+			fieldType = TypeTiny
+			buf = append(buf, byte(v))
+		case int16:
+			fieldType = TypeShort
+			buf = appendUint16(buf, uint16(v))
+		case int32:
+			fieldType = TypeLong
+			buf = appendUint32(buf, uint32(v))
+		case int64:
+			fieldType = TypeLongLong
+			buf = appendUint64(buf, uint64(v))
+		case uint:
+			fieldType, unsigned = TypeLongLong, true
+			buf = appendUint64(buf, uint64(v))
+		case uint8:
+			fieldType, unsigned = TypeTiny, true
+			buf = append(buf, v)
+		case uint16:
+			fieldType, unsigned = TypeShort, true
+			buf = appendUint16(buf, v)
+		case uint32:
+			fieldType, unsigned = TypeLong, true
+			buf = appendUint32(buf, v)
+		case uint64:
+			fieldType, unsigned = TypeLongLong, true
+			buf = appendUint64(buf, v)
+		case float32:
+			fieldType = TypeFloat
+			buf = appendUint32(buf, math.Float32bits(v))
+		case float64:
+			fieldType = TypeDouble
+			buf = appendUint64(buf, math.Float64bits(v))
+		case bool:
+			fieldType = TypeTiny
+			if v {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		case string:
+			fieldType = TypeString
+			buf = appendLenEncString(buf, v)
+		case []byte:
+			if v == nil {
+				buf[nullOffset+i/8] |= 1 << uint(i%8)
+				fieldType = TypeNULL
+			} else {
+				fieldType = TypeString
+				buf = appendLenEncBytes(buf, v)
+			}
+		case time.Time:
+			fieldType = TypeDateTime
+			buf = appendBinaryDateTime(buf, v)
+		default:
+			return buf, fmt.Errorf("mysqldriver: unsupported type %T of argument %d", arg, i)
+		}
+
+		buf[typesOffset+i*2] = byte(fieldType)
+		if unsigned {
+			buf[typesOffset+i*2+1] = 0x80
+		}
+	}
+
+	return buf, nil
+}
+
+func appendBinaryDateTime(buf []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(buf, 0)
+	}
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	micro := t.Nanosecond() / 1000
+	if micro == 0 {
+		buf = append(buf, 7)
+	} else {
+		buf = append(buf, 11)
+	}
+	buf = appendUint16(buf, uint16(year))
+	buf = append(buf, byte(month), byte(day), byte(hour), byte(min), byte(sec))
+	if micro != 0 {
+		buf = appendUint32(buf, uint32(micro))
+	}
+	return buf
+}
+
+// binaryNullBitmapOffset is the number of reserved bits
+// in the NULL bitmap of the binary row
+const binaryNullBitmapOffset = 2
+
+// binaryRowValuesOffset returns offset of the first value
+// in the binary row for the given number of columns
+func binaryRowValuesOffset(columns int) uint64 {
+	return 1 + uint64(columns+7+binaryNullBitmapOffset)/8
+}
+
+// binaryValueIsNULL checks NULL bitmap of the binary row
+func binaryValueIsNULL(packet []byte, column int) bool {
+	pos := column + binaryNullBitmapOffset
+	return packet[1+pos/8]&(1<<uint(pos%8)) != 0
+}
+
+// readBinaryValue returns raw bytes of the column's value of the binary row
+// (see https://dev.mysql.com/doc/internals/en/binary-protocol-value.html)
+func readBinaryValue(packet []byte, offset uint64, fieldType ColumnType) ([]byte, uint64) {
+	var length uint64
+	switch fieldType {
+	case TypeTiny:
+		length = 1
+	case TypeShort, TypeYear:
+		length = 2
+	case TypeLong, TypeInt24, TypeFloat:
+		length = 4
+	case TypeLongLong, TypeDouble:
+		length = 8
+	case TypeDate, TypeDateTime, TypeTimestamp, TypeTime:
+		length = uint64(packet[offset])
+		offset++
+	case TypeNULL:
+		length = 0
+	default:
+		value, next, _ := mysqlproto.ReadRowValue(packet, offset)
+		return value, next
+	}
+	return packet[offset : offset+length], offset + length
+}
+
+// binaryValueIsText reports whether values of the column type
+// are sent as strings by the binary protocol
+func binaryValueIsText(fieldType ColumnType) bool {
+	switch fieldType {
+	case TypeTiny, TypeShort, TypeYear,
+		TypeLong, TypeInt24, TypeLongLong,
+		TypeFloat, TypeDouble,
+		TypeDate, TypeDateTime, TypeTimestamp, TypeTime:
+		return false
+	}
+	return true
+}
+
+// binaryInt decodes value of an integer column. Unsigned values are
+// returned as bits of uint64. It returns false if column isn't an integer.
+func binaryInt(value []byte, column *mysqlproto.Column) (num uint64, unsigned bool, ok bool) {
+	unsigned = column.Flags&flagUnsigned != 0
+	switch ColumnType(column.ColumnType) {
+	case TypeTiny:
+		if unsigned {
+			return uint64(value[0]), true, true
+		}
+		return uint64(int8(value[0])), false, true
+	case TypeShort, TypeYear:
+		if unsigned {
+			return uint64(readUint16(value)), true, true
+		}
+		return uint64(int16(readUint16(value))), false, true
+	case TypeLong, TypeInt24:
+		if unsigned {
+			return uint64(readUint32(value)), true, true
+		}
+		return uint64(int32(readUint32(value))), false, true
+	case TypeLongLong:
+		return readUint64(value), unsigned, true
+	}
+	return 0, false, false
+}
+
+// binaryFloat decodes value of a numeric column as a float.
+// It returns false if column is neither an integer nor a float.
+func binaryFloat(value []byte, column *mysqlproto.Column) (float64, bool) {
+	switch ColumnType(column.ColumnType) {
+	case TypeFloat:
+		return float64(math.Float32frombits(readUint32(value))), true
+	case TypeDouble:
+		return math.Float64frombits(readUint64(value)), true
+	}
+
+	num, unsigned, ok := binaryInt(value, column)
+	if !ok {
+		return 0, false
+	}
+	if unsigned {
+		return float64(num), true
+	}
+	return float64(int64(num)), true
+}
+
+// checkIntRange verifies that integer decoded by binaryInt
+// fits into the signed integer of bitSize. Returned error
+// is the same as the one returned by strconv package.
+func checkIntRange(fn string, num uint64, unsigned bool, bitSize int) (int64, error) {
+	cutoff := uint64(1) << uint(bitSize-1)
+	if unsigned {
+		if num < cutoff {
+			return int64(num), nil
+		}
+		return int64(cutoff - 1), rangeError(fn, strconv.FormatUint(num, 10))
+	}
+
+	n := int64(num)
+	if n >= 0 && uint64(n) >= cutoff {
+		return int64(cutoff - 1), rangeError(fn, strconv.FormatInt(n, 10))
+	}
+	if n < 0 && uint64(-n) > cutoff {
+		return -int64(cutoff), rangeError(fn, strconv.FormatInt(n, 10))
+	}
+	return n, nil
+}
+
+// appendBinaryValueAsText converts value of the binary row
+// into the representation used by the text protocol
+func appendBinaryValueAsText(buf, value []byte, column mysqlproto.Column) []byte {
+	if num, unsigned, ok := binaryInt(value, &column); ok {
+		if unsigned {
+			return strconv.AppendUint(buf, num, 10)
+		}
+		return strconv.AppendInt(buf, int64(num), 10)
+	}
+
+	fieldType := ColumnType(column.ColumnType)
+	switch fieldType {
+	case TypeFloat:
+		return strconv.AppendFloat(buf, float64(math.Float32frombits(readUint32(value))), 'g', -1, 32)
+	case TypeDouble:
+		return strconv.AppendFloat(buf, math.Float64frombits(readUint64(value)), 'g', -1, 64)
+	case TypeDate, TypeDateTime, TypeTimestamp:
+		return appendBinaryDateTimeAsText(buf, value, fieldType, column.Decimals)
+	case TypeTime:
+		return appendBinaryTimeAsText(buf, value, column.Decimals)
+	default:
+		return append(buf, value...)
+	}
+}
+
+func appendBinaryDateTimeAsText(buf, value []byte, fieldType ColumnType, decimals byte) []byte {
+	var year, month, day, hour, min, sec, micro int
+	if len(value) >= 4 {
+		year, month, day = int(readUint16(value)), int(value[2]), int(value[3])
+	}
+	if len(value) >= 7 {
+		hour, min, sec = int(value[4]), int(value[5]), int(value[6])
+	}
+	if len(value) >= 11 {
+		micro = int(readUint32(value[7:]))
+	}
+
+	buf = appendDigits(buf, year, 4)
+	buf = append(buf, '-')
+	buf = appendDigits(buf, month, 2)
+	buf = append(buf, '-')
+	buf = appendDigits(buf, day, 2)
+	if fieldType == TypeDate {
+		return buf
+	}
+	buf = append(buf, ' ')
+	buf = appendDigits(buf, hour, 2)
+	buf = append(buf, ':')
+	buf = appendDigits(buf, min, 2)
+	buf = append(buf, ':')
+	buf = appendDigits(buf, sec, 2)
+	return appendFraction(buf, micro, decimals)
+}
+
+func appendBinaryTimeAsText(buf, value []byte, decimals byte) []byte {
+	var negative bool
+	var hours, min, sec, micro int
+	if len(value) >= 8 {
+		negative = value[0] == 1
+		hours = int(readUint32(value[1:]))*24 + int(value[5])
+		min, sec = int(value[6]), int(value[7])
+	}
+	if len(value) >= 12 {
+		micro = int(readUint32(value[8:]))
+	}
+
+	if negative {
+		buf = append(buf, '-')
+	}
+	buf = appendDigits(buf, hours, 2)
+	buf = append(buf, ':')
+	buf = appendDigits(buf, min, 2)
+	buf = append(buf, ':')
+	buf = appendDigits(buf, sec, 2)
+	return appendFraction(buf, micro, decimals)
+}
+
+// appendFraction appends fractional seconds with the precision of the column.
+// Decimals greater than 6 mean that precision is unknown.
+func appendFraction(buf []byte, micro int, decimals byte) []byte {
+	if decimals == 0 || (decimals > 6 && micro == 0) {
+		return buf
+	}
+	if decimals > 6 {
+		decimals = 6
+	}
+	var frac [6]byte
+	for i := len(frac) - 1; i >= 0; i-- {
+		frac[i] = byte('0' + micro%10)
+		micro /= 10
+	}
+	buf = append(buf, '.')
+	return append(buf, frac[:decimals]...)
+}
+
+// appendDigits appends non-negative number padded with zeros up to width
+func appendDigits(buf []byte, n, width int) []byte {
+	var digits [20]byte
+	i := len(digits)
+	for n >= 10 || width > 1 {
+		i--
+		digits[i] = byte('0' + n%10)
+		n /= 10
+		width--
+	}
+	i--
+	digits[i] = byte('0' + n)
+	return append(buf, digits[i:]...)
+}
@@ -0,0 +1,134 @@
+package mysqldriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendBinaryParams(t *testing.T) {
+	date := time.Date(2017, time.March, 4, 15, 6, 7, 8000, time.UTC)
+	buf, err := appendBinaryParams(nil, []interface{}{
+		nil, int64(-1), uint16(2), "ab", true, date,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, buf, []byte{
+		0x01,       // NULL bitmap
+		0x01,       // new params bound flag
+		0x06, 0x00, // NULL
+		0x08, 0x00, // LONGLONG
+		0x02, 0x80, // unsigned SHORT
+		0xfe, 0x00, // STRING
+		0x01, 0x00, // TINY
+		0x0c, 0x00, // DATETIME
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0x02, 0x00,
+		0x02, 'a', 'b',
+		0x01,
+		0x0b, 0xe1, 0x07, 0x03, 0x04, 0x0f, 0x06, 0x07, 0x08, 0x00, 0x00, 0x00,
+	})
+}
+
+func TestAppendBinaryParamsUnsupportedType(t *testing.T) {
+	_, err := appendBinaryParams(nil, []interface{}{1, struct{}{}})
+	assert.EqualError(t, err, "mysqldriver: unsupported type struct {} of argument 1")
+}
+
+func TestAppendBinaryValueAsText(t *testing.T) {
+	tests := []struct {
+		value    []byte
+		column   mysqlproto.Column
+		expected string
+	}{
+		{[]byte{0xff}, mysqlproto.Column{ColumnType: byte(TypeTiny)}, "-1"},
+		{[]byte{0xff}, mysqlproto.Column{ColumnType: byte(TypeTiny), Flags: flagUnsigned}, "255"},
+		{[]byte{0xfe, 0xff}, mysqlproto.Column{ColumnType: byte(TypeShort)}, "-2"},
+		{[]byte{0xe1, 0x07}, mysqlproto.Column{ColumnType: byte(TypeYear)}, "2017"},
+		{[]byte{0x01, 0x01, 0x00, 0x00}, mysqlproto.Column{ColumnType: byte(TypeLong)}, "257"},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, mysqlproto.Column{ColumnType: byte(TypeLongLong), Flags: flagUnsigned}, "18446744073709551615"},
+		{[]byte{0x00, 0x00, 0x90, 0x40}, mysqlproto.Column{ColumnType: byte(TypeFloat)}, "4.5"},
+		{[]byte{0x9a, 0x99, 0x99, 0x99, 0x99, 0x99, 0x0d, 0x40}, mysqlproto.Column{ColumnType: byte(TypeDouble)}, "3.7"},
+		{[]byte{0xe1, 0x07, 0x03, 0x04}, mysqlproto.Column{ColumnType: byte(TypeDate)}, "2017-03-04"},
+		{[]byte{}, mysqlproto.Column{ColumnType: byte(TypeDateTime)}, "0000-00-00 00:00:00"},
+		{[]byte{0xe1, 0x07, 0x03, 0x04, 0x0f, 0x06, 0x07}, mysqlproto.Column{ColumnType: byte(TypeDateTime)}, "2017-03-04 15:06:07"},
+		{[]byte{0xe1, 0x07, 0x03, 0x04, 0x0f, 0x06, 0x07, 0x08, 0x00, 0x00, 0x00}, mysqlproto.Column{ColumnType: byte(TypeTimestamp), Decimals: 3}, "2017-03-04 15:06:07.000"},
+		{[]byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x02, 0x03, 0x04}, mysqlproto.Column{ColumnType: byte(TypeTime)}, "-26:03:04"},
+		{[]byte("12.50"), mysqlproto.Column{ColumnType: byte(TypeNewDecimal)}, "12.50"},
+	}
+
+	for _, test := range tests {
+		value := appendBinaryValueAsText(nil, test.value, test.column)
+		assert.Equal(t, string(value), test.expected)
+	}
+}
+
+func TestRowsReadBinaryRow(t *testing.T) {
+	rows := &Rows{
+		resultSet: mysqlproto.ResultSet{Columns: []mysqlproto.Column{
+			{Name: "id", ColumnType: byte(TypeLongLong), Flags: flagUnsigned},
+			{Name: "name", ColumnType: byte(TypeVarString)},
+			{Name: "age", ColumnType: byte(TypeTiny)},
+			{Name: "score", ColumnType: byte(TypeDouble)},
+			{Name: "note", ColumnType: byte(TypeBLOB)},
+		}},
+		binary:  true,
+		columns: make(map[string]columnValue),
+	}
+	rows.packet = []byte{
+		0x00,                                           // header
+		0x40,                                           // NULL bitmap: note is NULL
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, // id
+		0x03, 'b', 'o', 'b', // name
+		0xc8,                                           // age
+		0x9a, 0x99, 0x99, 0x99, 0x99, 0x99, 0x0d, 0x40, // score
+	}
+	rows.offset = binaryRowValuesOffset(len(rows.resultSet.Columns))
+
+	assert.Equal(t, rows.Int64(), int64(9223372036854775807))
+	assert.EqualError(t, rows.LastError(), `strconv.ParseInt: parsing "9223372036854775809": value out of range`)
+	rows.errParse = nil
+	assert.Equal(t, rows.String(), "bob")
+	assert.Equal(t, rows.Int8(), int8(-56))
+	assert.Equal(t, rows.Float64(), 3.7)
+	note, null := rows.NullBytes()
+	assert.Equal(t, note, []byte{})
+	assert.True(t, null)
+	assert.NoError(t, rows.LastError())
+
+	row := rows.Row()
+	assert.Equal(t, row.String("id"), "9223372036854775809")
+	assert.Equal(t, row.Float64("id"), float64(9223372036854775809))
+	assert.Equal(t, row.Int("age"), -56)
+	assert.Equal(t, row.String("age"), "-56")
+	assert.Equal(t, row.Float32("score"), float32(3.7))
+	assert.Equal(t, row.String("score"), "3.7")
+	assert.NoError(t, rows.LastError())
+
+	assert.Equal(t, row.Int("name"), 0)
+	assert.EqualError(t, rows.LastError(), `strconv.Atoi: parsing "bob": invalid syntax`)
+	rows.errParse = nil
+	assert.Equal(t, row.Bool("age"), false)
+	assert.EqualError(t, rows.LastError(), `strconv.ParseBool: parsing "-56": invalid syntax`)
+}
+
+func TestCheckIntRange(t *testing.T) {
+	num, err := checkIntRange("ParseInt", uint64(127), false, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, num, int64(127))
+
+	num, err = checkIntRange("ParseInt", uint64(128), true, 8)
+	assert.EqualError(t, err, `strconv.ParseInt: parsing "128": value out of range`)
+	assert.Equal(t, num, int64(127))
+
+	minInt8 := int64(-128)
+	num, err = checkIntRange("ParseInt", uint64(minInt8), false, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, num, int64(-128))
+
+	minInt8--
+	num, err = checkIntRange("ParseInt", uint64(minInt8), false, 8)
+	assert.EqualError(t, err, `strconv.ParseInt: parsing "-129": value out of range`)
+	assert.Equal(t, num, int64(-128))
+}
@@ -0,0 +1,317 @@
+package mysqldriver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+var errBulkRowTooLarge = errors.New("mysqldriver: row of the bulk insert exceeds max_allowed_packet")
+
+// BulkInsertOptions holds the options of the bulk insert
+type BulkInsertOptions struct {
+	Ignore               bool   // INSERT IGNORE skips rows violating unique keys
+	Replace              bool   // REPLACE deletes rows violating unique keys before inserting
+	OnDuplicateKeyUpdate string // assignments of ON DUPLICATE KEY UPDATE clause, e.g. "age = VALUES(age)"
+}
+
+// BulkInserter inserts rows with multi-row INSERT statements.
+// Values are appended to the row one by one in the order of the columns.
+// Statement is executed once it would exceed max_allowed_packet
+// of the server, the rest of the rows is executed by Flush.
+// Inserter isn't safe for concurrent use. Once a statement fails,
+// the following values are ignored and the error is returned by Flush.
+type BulkInserter struct {
+	conn    *Conn
+	sql     string // statement reported to DB.QueryHook and errors
+	prefix  int    // length of the statement without rows
+	suffix  string // ON DUPLICATE KEY UPDATE clause
+	columns int
+	limit   int // max size of the statement
+
+	buf      []byte // COM_QUERY packet of the statement
+	row      []byte // row moved to the next statement
+	rowStart int    // position of the current row in buf
+	rows     int    // number of complete rows in buf
+	values   int    // number of values of the current row
+
+	result mysqlproto.OKPacket // see func (*BulkInserter) Flush
+	err    error
+}
+
+// BulkInsert creates an inserter of the rows into the table.
+// Size of the statements is limited by max_allowed_packet
+// which is queried from the server once per connection.
+//  inserter, err := conn.BulkInsert("dogs", []string{"name", "age"}, mysqldriver.BulkInsertOptions{})
+//  if err != nil {
+//  	// handle error
+//  }
+//  for _, dog := range dogs {
+//  	inserter.AppendString(dog.Name)
+//  	inserter.AppendInt(dog.Age)
+//  }
+//  pkt, err := inserter.Flush()
+//  if err != nil {
+//  	// handle error
+//  }
+//  pkt.AffectedRows // number of inserted dogs
+func (c *Conn) BulkInsert(table string, columns []string, opts BulkInsertOptions) (*BulkInserter, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("mysqldriver: bulk insert requires columns")
+	}
+	if opts.Replace && (opts.Ignore || opts.OnDuplicateKeyUpdate != "") {
+		return nil, errors.New("mysqldriver: REPLACE can't be combined with IGNORE or ON DUPLICATE KEY UPDATE")
+	}
+
+	limit, err := c.maxPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	var sql []byte
+	switch {
+	case opts.Replace:
+		sql = append(sql, "REPLACE INTO "...)
+	case opts.Ignore:
+		sql = append(sql, "INSERT IGNORE INTO "...)
+	default:
+		sql = append(sql, "INSERT INTO "...)
+	}
+	sql = append(sql, quoteIdentifier(table)...)
+	for i, column := range columns {
+		if i == 0 {
+			sql = append(sql, " ("...)
+		} else {
+			sql = append(sql, ", "...)
+		}
+		sql = append(sql, quoteIdentifier(column)...)
+	}
+	sql = append(sql, ") VALUES "...)
+
+	var suffix string
+	if opts.OnDuplicateKeyUpdate != "" {
+		suffix = " ON DUPLICATE KEY UPDATE " + opts.OnDuplicateKeyUpdate
+	}
+
+	b := &BulkInserter{
+		conn:    c,
+		sql:     string(sql) + "(...)" + suffix,
+		suffix:  suffix,
+		columns: len(columns),
+		limit:   limit,
+	}
+	b.buf = append(startPacket(nil, comQuery), sql...)
+	b.prefix = len(b.buf)
+	return b, nil
+}
+
+// defaultMaxAllowedPacket is assumed when the server doesn't report
+// max_allowed_packet. It's the default value in MySQL 5.7.
+const defaultMaxAllowedPacket = 4 << 20
+
+// maxPacket returns the largest COM_QUERY packet accepted by the server.
+// The value is queried once per connection.
+func (c *Conn) maxPacket() (int, error) {
+	if c.maxAllowedPacket == 0 {
+		rows, err := c.Query("SELECT @@max_allowed_packet")
+		if err != nil {
+			return 0, err
+		}
+		var size int
+		for rows.Next() {
+			size = rows.Int()
+		}
+		if err = rows.LastError(); err != nil {
+			return 0, err
+		}
+		if size <= 0 {
+			// cached anyway, so it isn't queried again
+			size = defaultMaxAllowedPacket
+		}
+		c.maxAllowedPacket = size
+	}
+	if c.maxAllowedPacket > maxPacketSize {
+		// requests split into several packets aren't supported
+		return maxPacketSize, nil
+	}
+	return c.maxAllowedPacket, nil
+}
+
+// AppendInt appends integer value to the row
+func (b *BulkInserter) AppendInt(v int64) {
+	if b.startValue() {
+		b.buf = strconv.AppendInt(b.buf, v, 10)
+		b.endValue()
+	}
+}
+
+// AppendUint appends unsigned integer value to the row
+func (b *BulkInserter) AppendUint(v uint64) {
+	if b.startValue() {
+		b.buf = strconv.AppendUint(b.buf, v, 10)
+		b.endValue()
+	}
+}
+
+// AppendFloat appends float value to the row.
+// NaN and infinity aren't supported by MySQL and fail the inserter.
+func (b *BulkInserter) AppendFloat(v float64) {
+	if b.startValue() {
+		b.buf, b.err = appendFloat(b.buf, v, 64, b.values+1)
+		b.endValue()
+	}
+}
+
+// AppendBool appends bool value to the row as 1 or 0
+func (b *BulkInserter) AppendBool(v bool) {
+	if b.startValue() {
+		if v {
+			b.buf = append(b.buf, '1')
+		} else {
+			b.buf = append(b.buf, '0')
+		}
+		b.endValue()
+	}
+}
+
+// AppendString appends string value to the row
+func (b *BulkInserter) AppendString(v string) {
+	if b.startValue() {
+		b.buf = appendStringLiteral(b.buf, v, b.conn.escapeMode())
+		b.endValue()
+	}
+}
+
+// AppendBytes appends binary value to the row.
+// Nil slice is appended as NULL.
+func (b *BulkInserter) AppendBytes(v []byte) {
+	if v == nil {
+		b.AppendNull()
+		return
+	}
+	if b.startValue() {
+		b.buf = appendBytesLiteral(b.buf, v, b.conn.escapeMode())
+		b.endValue()
+	}
+}
+
+// AppendTime appends time in its own location as DATETIME value
+func (b *BulkInserter) AppendTime(v time.Time) {
+	if b.startValue() {
+		b.buf = appendTime(b.buf, v)
+		b.endValue()
+	}
+}
+
+// AppendNull appends NULL to the row
+func (b *BulkInserter) AppendNull() {
+	if b.startValue() {
+		b.buf = append(b.buf, "NULL"...)
+		b.endValue()
+	}
+}
+
+// Flush executes the statement with the rest of the rows and returns
+// OK_PACKET aggregated from all executed statements. AffectedRows
+// and Warnings are summed up, other fields are taken from the last
+// statement. Incomplete row is discarded.
+func (b *BulkInserter) Flush() (mysqlproto.OKPacket, error) {
+	if b.values > 0 {
+		b.buf = b.buf[:b.rowStart]
+		b.values = 0
+	}
+	if b.err == nil && b.rows > 0 {
+		b.exec()
+	}
+	return b.result, b.err
+}
+
+// startValue appends the separator of the value.
+// It returns false when the inserter has failed.
+func (b *BulkInserter) startValue() bool {
+	if b.err != nil {
+		return false
+	}
+	if b.values == 0 {
+		b.rowStart = len(b.buf)
+		if b.rows > 0 {
+			b.buf = append(b.buf, ',')
+		}
+		b.buf = append(b.buf, '(')
+	} else {
+		b.buf = append(b.buf, ',')
+	}
+	return true
+}
+
+// endValue completes the row once all its values are appended
+// and executes the statement when the row doesn't fit into it
+func (b *BulkInserter) endValue() {
+	if b.err != nil {
+		return
+	}
+	b.values++
+	if b.values < b.columns {
+		return
+	}
+
+	b.buf = append(b.buf, ')')
+	b.values = 0
+	if b.size() <= b.limit {
+		b.rows++
+		return
+	}
+	if b.rows == 0 {
+		b.err = b.conn.queryError(b.sql, errBulkRowTooLarge)
+		return
+	}
+
+	// statement is executed without the row
+	// which becomes the first row of the next statement
+	b.row = append(b.row[:0], b.buf[b.rowStart+1:]...) // without separator
+	b.buf = b.buf[:b.rowStart]
+	if !b.exec() {
+		return
+	}
+	b.buf = append(b.buf, b.row...)
+	if b.size() > b.limit {
+		b.err = b.conn.queryError(b.sql, errBulkRowTooLarge)
+		return
+	}
+	b.rows = 1
+}
+
+// size returns size of COM_QUERY payload of the statement
+func (b *BulkInserter) size() int {
+	return len(b.buf) - 4 + len(b.suffix)
+}
+
+// exec executes the statement with complete rows
+// and resets the buffer to the statement without rows
+func (b *BulkInserter) exec() bool {
+	c := b.conn
+	trace := c.startQuery(context.Background(), b.sql, nil)
+	b.buf = append(b.buf, b.suffix...)
+	req, err := finishPacket(b.buf, 0)
+	var pkt mysqlproto.OKPacket
+	if err == nil {
+		pkt, err = c.exec(req)
+	}
+	err = c.queryError(b.sql, err)
+	trace.endExec(c, pkt, err)
+
+	b.buf = b.buf[:b.prefix]
+	b.rows = 0
+	if err != nil {
+		b.err = err
+		return false
+	}
+
+	affected, warnings := b.result.AffectedRows+pkt.AffectedRows, b.result.Warnings+pkt.Warnings
+	b.result = pkt
+	b.result.AffectedRows, b.result.Warnings = affected, warnings
+	return true
+}
@@ -0,0 +1,101 @@
+package mysqldriver
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dogsInsert = "INSERT INTO `dogs` (`name`, `age`) VALUES "
+
+func bulkConn(t *testing.T, maxAllowedPacket int, results map[string][][]byte) (*DB, *Conn, *fakeServer) {
+	results["SELECT @@max_allowed_packet"] = resultSet("@@max_allowed_packet", 0, strconv.Itoa(maxAllowedPacket))
+	return multiResultsConn(t, &fakeServer{results: results})
+}
+
+func TestBulkInsert(t *testing.T) {
+	// two rows fit into the statement
+	limit := len(dogsInsert) + len("('Rex',3),('Max',5)") + 1
+	db, conn, server := bulkConn(t, limit, map[string][][]byte{
+		dogsInsert + "('Rex',3),('Max',5)": {okPacket(2, 1, 0)},
+		dogsInsert + "('Tom',7)":           {okPacket(1, 3, 0)},
+	})
+	defer server.close()
+	defer db.Close()
+
+	inserter, err := conn.BulkInsert("dogs", []string{"name", "age"}, BulkInsertOptions{})
+	assert.NoError(t, err)
+	for i, name := range []string{"Rex", "Max", "Tom"} {
+		inserter.AppendString(name)
+		inserter.AppendInt(int64(3 + i*2))
+	}
+	inserter.AppendString("Bob") // incomplete row is discarded
+
+	pkt, err := inserter.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, pkt.AffectedRows, uint64(3))
+	assert.Equal(t, pkt.LastInsertID, uint64(3))
+	assert.Equal(t, conn.maxAllowedPacket, limit)
+}
+
+func TestBulkInsertOptions(t *testing.T) {
+	db, conn, server := bulkConn(t, 1024, map[string][][]byte{
+		"INSERT IGNORE INTO `dogs` (`name`, `age`) VALUES ('Rex',NULL) ON DUPLICATE KEY UPDATE age = VALUES(age)": {okPacket(2, 0, 0)},
+		"REPLACE INTO `dogs` (`name`, `age`) VALUES (_binary'R\\\\ex',1.5)":                                       {okPacket(1, 0, 0)},
+	})
+	defer server.close()
+	defer db.Close()
+
+	inserter, err := conn.BulkInsert("dogs", []string{"name", "age"}, BulkInsertOptions{
+		Ignore:               true,
+		OnDuplicateKeyUpdate: "age = VALUES(age)",
+	})
+	assert.NoError(t, err)
+	inserter.AppendString("Rex")
+	inserter.AppendNull()
+	pkt, err := inserter.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, pkt.AffectedRows, uint64(2))
+
+	inserter, err = conn.BulkInsert("dogs", []string{"name", "age"}, BulkInsertOptions{Replace: true})
+	assert.NoError(t, err)
+	inserter.AppendBytes([]byte("R\\ex"))
+	inserter.AppendFloat(1.5)
+	pkt, err = inserter.Flush()
+	assert.NoError(t, err)
+	assert.Equal(t, pkt.AffectedRows, uint64(1))
+
+	_, err = conn.BulkInsert("dogs", []string{"name"}, BulkInsertOptions{Replace: true, Ignore: true})
+	assert.Error(t, err)
+}
+
+func TestBulkInsertRowTooLarge(t *testing.T) {
+	db, conn, server := bulkConn(t, len(dogsInsert)+10, map[string][][]byte{})
+	defer server.close()
+	defer db.Close()
+
+	inserter, err := conn.BulkInsert("dogs", []string{"name", "age"}, BulkInsertOptions{})
+	assert.NoError(t, err)
+	inserter.AppendString("Rex the Great")
+	inserter.AppendInt(3)
+	inserter.AppendString("Max") // ignored after the error
+	inserter.AppendInt(5)
+	_, err = inserter.Flush()
+	assert.Equal(t, err, errBulkRowTooLarge)
+	assert.Equal(t, server.receivedCommands(), []byte{comQuery})
+}
+
+func TestBulkInsertMaxPacketUnknown(t *testing.T) {
+	db, conn, server := multiResultsConn(t, &fakeServer{})
+	defer server.close()
+	defer db.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := conn.BulkInsert("dogs", []string{"name", "age"}, BulkInsertOptions{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, conn.maxAllowedPacket, defaultMaxAllowedPacket)
+	// max_allowed_packet is queried once
+	assert.Equal(t, server.receivedCommands(), []byte{comQuery})
+}
@@ -0,0 +1,126 @@
+package mysqldriver
+
+import (
+	"strings"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// Out marks OUT or INOUT parameter of the stored procedure
+// (see func (*Conn) Call). Value of the parameter is stored into
+// the variable pointed by Dest the same way as by func (*Rows) Scan.
+type Out struct {
+	Dest interface{} // pointer to the variable receiving the value
+	In   interface{} // value passed to INOUT parameter, nil for OUT parameter
+}
+
+// Call calls the stored procedure with the arguments using
+// the binary protocol. Returned rows hold result sets produced
+// by the procedure (see func (*Rows) NextResultSet). When the procedure
+// doesn't produce any, rows are empty. Final status of the procedure
+// isn't represented as a result set. OUT and INOUT parameters
+// are passed with Out and they're set once all result sets are read.
+//  var total int64
+//  rows, err := conn.Call("count_dogs", "Rex", mysqldriver.Out{Dest: &total})
+//  if err != nil {
+//  	// handle error
+//  }
+//  for rows.Next() {
+//  	rows.String() // dog's name
+//  }
+//  for rows.NextResultSet() {
+//  	// read the rest of result sets
+//  }
+//  if err = rows.LastError(); err != nil {
+//  	// handle error
+//  }
+//  // total is set
+// Name of the procedure may be qualified with the database name, e.g. "db.proc".
+func (c *Conn) Call(name string, args ...interface{}) (*Rows, error) {
+	params := make([]interface{}, len(args))
+	outs := make([]interface{}, 0, len(args)) // not nil, so OUT parameters are recognized
+	for i, arg := range args {
+		if out, ok := arg.(Out); ok {
+			params[i] = out.In
+			outs = append(outs, out.Dest)
+		} else {
+			params[i] = arg
+		}
+	}
+
+	stmt, err := c.Prepare(callSQL(name, len(args)))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(params...)
+	// statement isn't needed any more once it's executed and server
+	// doesn't respond to COM_STMT_CLOSE, so the result isn't affected
+	if closeErr := stmt.Close(); err == nil && closeErr != nil {
+		return nil, closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows.outs = outs
+	if err = rows.readOutParams(); err != nil {
+		rows.errRead = err
+		rows.finish()
+	}
+	return rows, nil
+}
+
+// callSQL builds CALL statement with placeholders of the arguments
+func callSQL(name string, args int) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part)
+	}
+
+	sql := "CALL " + strings.Join(parts, ".") + "("
+	for i := 0; i < args; i++ {
+		if i > 0 {
+			sql += ", "
+		}
+		sql += "?"
+	}
+	return sql + ")"
+}
+
+// readOutParams reads the result set holding OUT parameters
+// of the procedure into their destinations and moves to the
+// next result set, so it's hidden from the caller.
+func (r *Rows) readOutParams() error {
+	c := r.conn
+	if r.outs == nil || c.status&serverPSOutParams == 0 || r.empty {
+		return nil
+	}
+
+	packet, err := c.readRow()
+	if err != nil {
+		return err
+	}
+	if packet != nil {
+		r.packet = packet
+		r.offset = binaryRowValuesOffset(len(r.resultSet.Columns))
+		r.buf = r.buf[:0]
+		err = r.Scan(r.outs...)
+		// rest of the result set is read even if values can't be scanned
+		if skipErr := c.skipRows(); err == nil {
+			err = skipErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var columns []mysqlproto.Column
+	if c.moreResults() {
+		// OK_PACKET with the status of the procedure follows
+		if columns, _, err = c.readResultSet(); err != nil {
+			return err
+		}
+	}
+	r.reset(columns)
+	return r.readOutParams()
+}
@@ -0,0 +1,96 @@
+package mysqldriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareOK returns packets of COM_STMT_PREPARE response
+// of the statement without columns
+func prepareOK(params int) [][]byte {
+	packets := [][]byte{appendUint16(appendUint16(appendUint32([]byte{0}, 1), 0), uint16(params))}
+	packets[0] = append(packets[0], 0, 0, 0)
+	if params == 0 {
+		return packets
+	}
+	for i := 0; i < params; i++ {
+		packets = append(packets, columnPacket("?"))
+	}
+	return append(packets, eofPacket(0))
+}
+
+// binaryResultSet returns packets of the binary result set
+// with a single column, status is sent after column definitions as well
+func binaryResultSet(column string, status uint16, values ...string) [][]byte {
+	packets := [][]byte{{1}, columnPacket(column), eofPacket(status)}
+	for _, value := range values {
+		packets = append(packets, appendLenEncString([]byte{0, 0}, value))
+	}
+	return append(packets, eofPacket(status&^serverPSOutParams))
+}
+
+func TestCall(t *testing.T) {
+	packets := binaryResultSet("dog", serverMoreResultsExists, "Rex", "Max")
+	packets = append(packets, binaryResultSet("name", serverMoreResultsExists|serverPSOutParams, "Tom")...)
+	packets = append(packets, okPacket(0, 0, 0))
+	db, conn, server := multiResultsConn(t, &fakeServer{replies: [][][]byte{prepareOK(2), packets}})
+	defer server.close()
+	defer db.Close()
+
+	var name string
+	rows, err := conn.Call("test.dogs", "Rex", Out{Dest: &name, In: "Tim"})
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.String(), "Rex")
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.String(), "Max")
+	assert.False(t, rows.Next())
+	assert.Equal(t, name, "")
+
+	// result set of OUT parameters and the final status are hidden
+	assert.False(t, rows.NextResultSet())
+	assert.NoError(t, rows.LastError())
+	assert.Equal(t, name, "Tom")
+
+	// connection is in sync
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	assert.Len(t, db.conns, 1)
+	assert.Equal(t, server.receivedCommands(), []byte{comStmtPrepare, comStmtExecute, comStmtClose, comQuery})
+}
+
+func TestCallOutParamsOnly(t *testing.T) {
+	packets := binaryResultSet("total", serverMoreResultsExists|serverPSOutParams, "3")
+	packets = append(packets, okPacket(0, 0, 0))
+	db, conn, server := multiResultsConn(t, &fakeServer{replies: [][][]byte{prepareOK(1), packets}})
+	defer server.close()
+	defer db.Close()
+
+	var total int64
+	rows, err := conn.Call("count_dogs", Out{Dest: &total})
+	assert.NoError(t, err)
+	assert.Equal(t, total, int64(3))
+	assert.Len(t, rows.Columns(), 0)
+	assert.False(t, rows.Next())
+	assert.False(t, rows.NextResultSet())
+	assert.NoError(t, rows.LastError())
+}
+
+func TestCallWithoutResultSets(t *testing.T) {
+	db, conn, server := multiResultsConn(t, &fakeServer{replies: [][][]byte{prepareOK(0), {okPacket(0, 0, 0)}}})
+	defer server.close()
+	defer db.Close()
+
+	rows, err := conn.Call("cleanup")
+	assert.NoError(t, err)
+	assert.False(t, rows.Next())
+	assert.False(t, rows.NextResultSet())
+	assert.NoError(t, rows.LastError())
+}
+
+func TestCallSQL(t *testing.T) {
+	assert.Equal(t, callSQL("db.proc", 2), "CALL `db`.`proc`(?, ?)")
+	assert.Equal(t, callSQL("proc", 0), "CALL `proc`()")
+}
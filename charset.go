@@ -0,0 +1,106 @@
+package mysqldriver
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultCharset is used when charset of the connection isn't set
+const defaultCharset = "utf8mb4"
+
+// IDs of collations which can be negotiated in the handshake
+// (see SELECT id, collation_name FROM information_schema.collations)
+var collationIDs = map[string]byte{
+	"big5_chinese_ci":    1,
+	"latin1_german1_ci":  5,
+	"latin1_swedish_ci":  8,
+	"ascii_general_ci":   11,
+	"latin1_danish_ci":   15,
+	"latin1_german2_ci":  31,
+	"utf8_general_ci":    33,
+	"utf8mb3_general_ci": 33,
+	"utf8mb4_general_ci": 45,
+	"utf8mb4_bin":        46,
+	"latin1_bin":         47,
+	"latin1_general_ci":  48,
+	"latin1_general_cs":  49,
+	"binary":             63,
+	"ascii_bin":          65,
+	"utf8_bin":           83,
+	"utf8mb3_bin":        83,
+	"latin1_spanish_ci":  94,
+	"utf8_unicode_ci":    192,
+	"utf8mb3_unicode_ci": 192,
+	"utf8mb4_unicode_ci": 224,
+	"utf8mb4_0900_ai_ci": 255,
+}
+
+// default collations of the charsets
+var charsetCollations = map[string]string{
+	"big5":    "big5_chinese_ci",
+	"latin1":  "latin1_swedish_ci",
+	"ascii":   "ascii_general_ci",
+	"utf8":    "utf8_general_ci",
+	"utf8mb3": "utf8mb3_general_ci",
+	"utf8mb4": "utf8mb4_general_ci",
+	"binary":  "binary",
+}
+
+// latin1Collations are IDs of collations of latin1 charset
+var latin1Collations = map[uint16]bool{5: true, 8: true, 15: true, 31: true, 47: true, 48: true, 49: true, 94: true}
+
+// MySQL's latin1 is cp1252, so 0x80-0x9f are mapped to these characters.
+// Bytes which aren't defined in cp1252 are mapped to the same code points.
+var cp1252 = [32]rune{
+	0x20ac, 0x0081, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008d, 0x017d, 0x008f,
+	0x0090, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0x009d, 0x017e, 0x0178,
+}
+
+// negotiateCharset returns charset of the connection and ID of the collation
+// sent in the handshake. When the collation can't be negotiated,
+// false is returned and SET NAMES command has to be sent instead.
+func negotiateCharset(charset, collation string) (string, byte, bool) {
+	if collation != "" {
+		collationCharset := collation
+		if i := strings.IndexByte(collation, '_'); i > 0 {
+			collationCharset = collation[:i]
+		}
+		if charset == "" {
+			charset = collationCharset
+		}
+		id, ok := collationIDs[collation]
+		return charset, id, ok && charset == collationCharset
+	}
+
+	if charset == "" {
+		charset = defaultCharset
+	}
+	id, ok := collationIDs[charsetCollations[charset]]
+	return charset, id, ok
+}
+
+// latin1ToString converts latin1 encoded text to UTF-8 string
+func latin1ToString(b []byte) string {
+	i := 0
+	for i < len(b) && b[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(b) {
+		return string(b) // ASCII is the same in both encodings
+	}
+
+	buf := make([]byte, i, len(b)*3)
+	copy(buf, b[:i])
+	var encoded [utf8.UTFMax]byte
+	for _, ch := range b[i:] {
+		r := rune(ch)
+		if ch >= 0x80 && ch < 0xa0 {
+			r = cp1252[ch-0x80]
+		}
+		n := utf8.EncodeRune(encoded[:], r)
+		buf = append(buf, encoded[:n]...)
+	}
+	return string(buf)
+}
@@ -0,0 +1,92 @@
+package mysqldriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateCharset(t *testing.T) {
+	charset, id, ok := negotiateCharset("", "")
+	assert.Equal(t, charset, "utf8mb4")
+	assert.Equal(t, id, byte(45))
+	assert.True(t, ok)
+
+	charset, id, ok = negotiateCharset("latin1", "")
+	assert.Equal(t, charset, "latin1")
+	assert.Equal(t, id, byte(8))
+	assert.True(t, ok)
+
+	charset, id, ok = negotiateCharset("", "utf8mb4_unicode_ci")
+	assert.Equal(t, charset, "utf8mb4")
+	assert.Equal(t, id, byte(224))
+	assert.True(t, ok)
+
+	charset, id, ok = negotiateCharset("utf8mb4", "utf8mb4_bin")
+	assert.Equal(t, charset, "utf8mb4")
+	assert.Equal(t, id, byte(46))
+	assert.True(t, ok)
+}
+
+func TestNegotiateCharsetFallback(t *testing.T) {
+	charset, _, ok := negotiateCharset("cp1251", "")
+	assert.Equal(t, charset, "cp1251")
+	assert.False(t, ok)
+
+	charset, _, ok = negotiateCharset("", "utf8mb4_polish_ci")
+	assert.Equal(t, charset, "utf8mb4")
+	assert.False(t, ok)
+
+	charset, _, ok = negotiateCharset("latin1", "utf8mb4_bin")
+	assert.Equal(t, charset, "latin1")
+	assert.False(t, ok)
+}
+
+func TestLatin1ToString(t *testing.T) {
+	assert.Equal(t, latin1ToString([]byte("plain ascii")), "plain ascii")
+	assert.Equal(t, latin1ToString([]byte{'c', 'a', 'f', 0xe9}), "café")
+	assert.Equal(t, latin1ToString([]byte{0x80, ' ', 0x99, ' ', 0x81}), "€ ™ \u0081")
+	assert.Equal(t, latin1ToString(nil), "")
+}
+
+func TestHandshakeNegotiatesCollation(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+
+	db := NewDB("root@tcp("+server.addr()+")/test?collation=utf8mb4_unicode_ci", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.Equal(t, server.collation, byte(224))
+	assert.Equal(t, conn.Charset(), "utf8mb4")
+}
+
+func TestConnDefaultCharset(t *testing.T) {
+	conn, err := NewConn("root", "", "tcp", "127.0.0.1:3306", "test", time.Duration(0))
+	assert.NoError(t, err)
+	assert.Equal(t, conn.Charset(), "utf8mb4")
+
+	rows, err := conn.Query("SELECT @@character_set_connection, CONVERT(0xF09F9880 USING utf8mb4)")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.String(), "utf8mb4")
+	assert.Equal(t, rows.String(), "😀")
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.LastError())
+}
+
+func TestConnTranscodeLatin1(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test?charset=latin1&transcodeLatin1=true", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.Equal(t, conn.Charset(), "latin1")
+
+	rows, err := conn.Query("SELECT CONVERT(0x636166E9 USING latin1), CONVERT(0x636166E9 USING latin1)")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.String(), "café")
+	assert.Equal(t, rows.Bytes(), []byte{'c', 'a', 'f', 0xe9})
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.LastError())
+}
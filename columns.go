@@ -0,0 +1,150 @@
+package mysqldriver
+
+// ColumnType is a type of the column as reported by MySQL
+// (see https://dev.mysql.com/doc/internals/en/com-query-response.html#column-type)
+type ColumnType byte
+
+const (
+	TypeDecimal    ColumnType = 0x00
+	TypeTiny       ColumnType = 0x01
+	TypeShort      ColumnType = 0x02
+	TypeLong       ColumnType = 0x03
+	TypeFloat      ColumnType = 0x04
+	TypeDouble     ColumnType = 0x05
+	TypeNULL       ColumnType = 0x06
+	TypeTimestamp  ColumnType = 0x07
+	TypeLongLong   ColumnType = 0x08
+	TypeInt24      ColumnType = 0x09
+	TypeDate       ColumnType = 0x0a
+	TypeTime       ColumnType = 0x0b
+	TypeDateTime   ColumnType = 0x0c
+	TypeYear       ColumnType = 0x0d
+	TypeNewDate    ColumnType = 0x0e
+	TypeVarChar    ColumnType = 0x0f
+	TypeBit        ColumnType = 0x10
+	TypeJSON       ColumnType = 0xf5
+	TypeNewDecimal ColumnType = 0xf6
+	TypeEnum       ColumnType = 0xf7
+	TypeSet        ColumnType = 0xf8
+	TypeTinyBLOB   ColumnType = 0xf9
+	TypeMediumBLOB ColumnType = 0xfa
+	TypeLongBLOB   ColumnType = 0xfb
+	TypeBLOB       ColumnType = 0xfc
+	TypeVarString  ColumnType = 0xfd
+	TypeString     ColumnType = 0xfe
+	TypeGeometry   ColumnType = 0xff
+)
+
+var columnTypeNames = map[ColumnType]string{
+	TypeDecimal:    "DECIMAL",
+	TypeTiny:       "TINY",
+	TypeShort:      "SHORT",
+	TypeLong:       "LONG",
+	TypeFloat:      "FLOAT",
+	TypeDouble:     "DOUBLE",
+	TypeNULL:       "NULL",
+	TypeTimestamp:  "TIMESTAMP",
+	TypeLongLong:   "LONGLONG",
+	TypeInt24:      "INT24",
+	TypeDate:       "DATE",
+	TypeTime:       "TIME",
+	TypeDateTime:   "DATETIME",
+	TypeYear:       "YEAR",
+	TypeNewDate:    "NEWDATE",
+	TypeVarChar:    "VARCHAR",
+	TypeBit:        "BIT",
+	TypeJSON:       "JSON",
+	TypeNewDecimal: "NEWDECIMAL",
+	TypeEnum:       "ENUM",
+	TypeSet:        "SET",
+	TypeTinyBLOB:   "TINY_BLOB",
+	TypeMediumBLOB: "MEDIUM_BLOB",
+	TypeLongBLOB:   "LONG_BLOB",
+	TypeBLOB:       "BLOB",
+	TypeVarString:  "VAR_STRING",
+	TypeString:     "STRING",
+	TypeGeometry:   "GEOMETRY",
+}
+
+// String returns name of the type as it's called in MySQL protocol
+func (t ColumnType) String() string {
+	if name, ok := columnTypeNames[t]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// Column definition flags
+// (see https://dev.mysql.com/doc/dev/mysql-server/latest/group__group__cs__column__definition__flags.html)
+const (
+	flagNotNull    uint16 = 0x0001
+	flagPrimaryKey uint16 = 0x0002
+	flagUnsigned   uint16 = 0x0020
+	flagBinary     uint16 = 0x0080
+)
+
+// ColumnInfo describes column of the result set
+type ColumnInfo struct {
+	Name     string     // name of the column or its alias
+	OrgName  string     // original name of the column
+	Table    string     // name of the table or its alias
+	OrgTable string     // original name of the table
+	Schema   string     // name of the database
+	Type     ColumnType // type of the column
+	Flags    uint16     // column definition flags
+	Charset  uint16     // collation ID of the column
+	Length   uint32     // maximum length of the column's value
+	Decimals byte       // number of decimals of numeric and temporal columns
+}
+
+// NotNull reports whether column is declared as NOT NULL
+func (c ColumnInfo) NotNull() bool {
+	return c.Flags&flagNotNull != 0
+}
+
+// Unsigned reports whether numeric column is declared as UNSIGNED
+func (c ColumnInfo) Unsigned() bool {
+	return c.Flags&flagUnsigned != 0
+}
+
+// PrimaryKey reports whether column is a part of the primary key
+func (c ColumnInfo) PrimaryKey() bool {
+	return c.Flags&flagPrimaryKey != 0
+}
+
+// Binary reports whether column contains binary data
+func (c ColumnInfo) Binary() bool {
+	return c.Flags&flagBinary != 0
+}
+
+// Columns returns definitions of the columns of the result set
+// in the same order as they are read. Returned slice is shared
+// between calls and mustn't be modified.
+//  rows, _ := conn.Query("SELECT id, name FROM dogs")
+//  for _, column := range rows.Columns() {
+//  	fmt.Println(column.Name, column.Type)
+//  }
+func (r *Rows) Columns() []ColumnInfo {
+	if r.columnInfo != nil {
+		return r.columnInfo
+	}
+
+	info := make([]ColumnInfo, len(r.resultSet.Columns))
+	for i, c := range r.resultSet.Columns {
+		info[i] = ColumnInfo{
+			Name:     c.Name,
+			OrgName:  c.OrgName,
+			Table:    c.Table,
+			OrgTable: c.OrgTable,
+			Schema:   c.Schema,
+			Type:     ColumnType(c.ColumnType),
+			Flags:    c.Flags,
+			Charset:  c.CharacterSet,
+			Length:   c.ColumnLength,
+			Decimals: c.Decimals,
+		}
+	}
+	r.columnInfo = info
+
+	return info
+}
@@ -0,0 +1,59 @@
+package mysqldriver
+
+import (
+	"testing"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowsColumns(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		rows, err := conn.Query("SELECT p.id, firstname AS name, score FROM people AS p")
+		assert.NoError(t, err)
+
+		columns := rows.Columns()
+		assert.Len(t, columns, 3)
+
+		assert.Equal(t, columns[0].Name, "id")
+		assert.Equal(t, columns[0].OrgName, "id")
+		assert.Equal(t, columns[0].Table, "p")
+		assert.Equal(t, columns[0].OrgTable, "people")
+		assert.Equal(t, columns[0].Schema, "test")
+		assert.Equal(t, columns[0].Type, TypeLong)
+		assert.True(t, columns[0].NotNull())
+		assert.True(t, columns[0].PrimaryKey())
+		assert.False(t, columns[0].Unsigned())
+
+		assert.Equal(t, columns[1].Name, "name")
+		assert.Equal(t, columns[1].OrgName, "firstname")
+		assert.Equal(t, columns[1].Type, TypeVarString)
+		assert.False(t, columns[1].NotNull())
+		assert.False(t, columns[1].Binary())
+
+		assert.Equal(t, columns[2].Name, "score")
+		assert.Equal(t, columns[2].Type, TypeNewDecimal)
+		assert.Equal(t, columns[2].Decimals, byte(2))
+
+		assert.False(t, rows.Next())
+	})
+}
+
+func TestRowsColumnsAreCached(t *testing.T) {
+	rows := &Rows{resultSet: mysqlproto.ResultSet{Columns: []mysqlproto.Column{
+		{Name: "id", ColumnType: byte(TypeLongLong), Flags: flagUnsigned | flagBinary, CharacterSet: 63},
+	}}}
+	columns := rows.Columns()
+	assert.Equal(t, columns, []ColumnInfo{
+		{Name: "id", Type: TypeLongLong, Flags: flagUnsigned | flagBinary, Charset: 63},
+	})
+	assert.True(t, columns[0].Unsigned())
+	assert.True(t, columns[0].Binary())
+	assert.True(t, &rows.Columns()[0] == &columns[0])
+}
+
+func TestColumnTypeString(t *testing.T) {
+	assert.Equal(t, TypeVarChar.String(), "VARCHAR")
+	assert.Equal(t, TypeJSON.String(), "JSON")
+	assert.Equal(t, ColumnType(0x20).String(), "UNKNOWN")
+}
@@ -2,6 +2,7 @@ package mysqldriver
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"time"
 
@@ -13,91 +14,275 @@ var capabilityFlags = mysqlproto.CLIENT_LONG_PASSWORD |
 	mysqlproto.CLIENT_LONG_FLAG |
 	mysqlproto.CLIENT_CONNECT_WITH_DB |
 	mysqlproto.CLIENT_PLUGIN_AUTH |
+	mysqlproto.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA |
 	mysqlproto.CLIENT_TRANSACTIONS |
 	mysqlproto.CLIENT_PROTOCOL_41 |
 	mysqlproto.CLIENT_SECURE_CONNECTION |
-	mysqlproto.CLIENT_SESSION_TRACK
+	mysqlproto.CLIENT_SESSION_TRACK |
+	mysqlproto.CLIENT_MULTI_RESULTS | // result sets of stored procedures
+	mysqlproto.CLIENT_PS_MULTI_RESULTS |
+	mysqlproto.CLIENT_LOCAL_FILES // see func (*Conn) LoadData
+
+// Server status flags reported in OK_PACKET
+// (see https://dev.mysql.com/doc/internals/en/status-flags.html)
+const (
+	serverStatusInTrans            uint16 = 0x0001
+	serverMoreResultsExists        uint16 = 0x0008
+	serverPSOutParams              uint16 = 0x1000 // result set holds OUT parameters of the procedure
+	serverStatusNoBackslashEscapes uint16 = 0x0200
+)
 
 // Conn represents connection to MySQL server
 type Conn struct {
 	conn   mysqlproto.Conn
 	valid  bool
 	closed bool
+
+	buf    []byte // reusable buffer to build requests
+	status uint16 // server status flags of the last OK_PACKET
+
+	charset         string // charset of the connection
+	transcodeLatin1 bool   // see func (*Conn) SetTranscodeLatin1
+	multiStatements bool   // see Config.MultiStatements
+
+	netConn      net.Conn  // underlying connection used to set deadlines
+	meter        meter     // counts bytes transferred over netConn
+	db           *DB       // pool which established the connection
+	createdAt    time.Time // when the pool established the connection
+	idleSince    time.Time // when the connection was returned to the pool
+	connectionID uint32    // thread ID of the connection on the server
+	watcher      *watcher  // see func (*Conn) QueryContext
+
+	maxAllowedPacket int // max_allowed_packet of the server, 0 until it's queried
+}
+
+// Steps of establishing the connection
+const (
+	ConnectStepDial = "dial" // establishing network connection
+	ConnectStepAuth = "auth" // handshake and authentication
+	ConnectStepInit = "init" // initialization of the connection including DB.OnDial
+)
+
+// ConnectError is returned when establishing of the connection
+// is interrupted because its context is done
+type ConnectError struct {
+	Step  string // step which was interrupted
+	Err   error  // error of the context
+	Cause error  // error which interrupted the step
+}
+
+func (e *ConnectError) Error() string {
+	return "mysqldriver: " + e.Step + " step interrupted: " + e.Err.Error() + ": " + e.Cause.Error()
+}
+
+// Unwrap returns error of the context
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// connectError wraps the error into *ConnectError
+// when it's caused by the context
+func connectError(ctx context.Context, step string, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		// socket deadline may expire earlier than the context
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Now().Before(deadline) {
+			return err
+		}
+		ctxErr = context.DeadlineExceeded
+	}
+	return &ConnectError{Step: step, Err: ctxErr, Cause: err}
 }
 
 // Contains connection statistics
 type Stats struct {
-	Syscalls int // number of system calls performed to read all packets
+	Syscalls     int    // number of system calls performed to read all packets
+	BytesRead    uint64 // number of bytes read from the network
+	BytesWritten uint64 // number of bytes written to the network
 }
 
-// NewConn establishes a connection to the DB. After obtaining the connection,
-// it sends "SET NAMES utf8" command to the DB
+// NewConn establishes a connection to the DB using utf8mb4 charset
 func NewConn(username, password, protocol, address, database string, readTimeout time.Duration) (*Conn, error) {
 	return NewConnContext(context.Background(), username, password, protocol, address, database, readTimeout)
 }
 
-// NewConnContext establishes a connection to the DB. After obtaining the connection,
-// it sends "SET NAMES utf8" command to the DB
+// NewConnContext establishes a connection to the DB using utf8mb4 charset
 //
-// Go Context is only used to establish a TCP connection.
-// TODO use Go Context to establish a MySQL connection.
+// Go Context bounds the whole sequence of establishing the connection:
+// dialing, authentication and initialization of the connection.
+// When the context is done before the connection is established,
+// *ConnectError is returned describing which step was interrupted.
 func NewConnContext(ctx context.Context, username, password, protocol, address,
 	database string, readTimeout time.Duration) (*Conn, error) {
+	return NewConnTLSContext(ctx, username, password, protocol, address, database, readTimeout, nil)
+}
 
-	conn, err := (&net.Dialer{}).DialContext(ctx, protocol, address)
-	if err != nil {
-		return nil, err
+// NewConnTLS is the same as NewConn, but the connection
+// is encrypted with TLS (see func NewConnTLSContext)
+func NewConnTLS(username, password, protocol, address, database string,
+	readTimeout time.Duration, config *tls.Config) (*Conn, error) {
+	return NewConnTLSContext(context.Background(), username, password, protocol, address, database, readTimeout, config)
+}
+
+// NewConnTLSContext is the same as NewConnContext, but the connection
+// is upgraded to TLS before authentication. When ServerName of the config
+// is empty, it's taken from the address. Nil config means that TLS isn't used.
+func NewConnTLSContext(ctx context.Context, username, password, protocol, address,
+	database string, readTimeout time.Duration, config *tls.Config) (*Conn, error) {
+	cfg := &Config{
+		User:        username,
+		Password:    password,
+		Net:         protocol,
+		Addr:        address,
+		DBName:      database,
+		ReadTimeout: readTimeout,
 	}
+	return connect(ctx, cfg, config)
+}
 
-	stream, err := mysqlproto.ConnectPlainHandshake(
-		conn, capabilityFlags,
-		username, password, database, nil, readTimeout,
-	)
+// NewConnFromConfig establishes a connection with the settings
+// of the config (see func ParseDSN). Pool settings are ignored.
+// Go Context bounds establishing of the connection the same way
+// as for func NewConnContext.
+func NewConnFromConfig(ctx context.Context, cfg *Config) (*Conn, error) {
+	config := cfg.TLSConfig
+	if config == nil {
+		var err error
+		if config, err = tlsConfigByName(cfg.TLS); err != nil {
+			return nil, err
+		}
+	}
+	return connect(ctx, cfg, config)
+}
+
+// connect establishes the connection with the given settings
+func connect(ctx context.Context, cfg *Config, config *tls.Config) (*Conn, error) {
+	if config != nil && config.ServerName == "" && !config.InsecureSkipVerify {
+		config = config.Clone()
+		config.ServerName = hostname(cfg.Addr)
+	}
 
+	conn, err := (&net.Dialer{Timeout: cfg.Timeout}).DialContext(ctx, cfg.Net, cfg.Addr)
 	if err != nil {
-		return &Conn{conn: stream, valid: false, closed: false}, err
+		return nil, connectError(ctx, ConnectStepDial, err)
+	}
+
+	release := interruptible(ctx, conn)
+
+	charset, collation, negotiated := negotiateCharset(cfg.Charset, cfg.Collation)
+	if !negotiated {
+		// SET NAMES is sent after the handshake
+		_, collation, _ = negotiateCharset(defaultCharset, "")
+	}
+
+	c := &Conn{
+		valid:           false,
+		closed:          false,
+		netConn:         conn,
+		charset:         charset,
+		transcodeLatin1: cfg.TranscodeLatin1,
+		multiStatements: cfg.MultiStatements,
+	}
+	if err = c.handshake(cfg, collation, config); err != nil {
+		release()
+		return c, connectError(ctx, ConnectStepAuth, err)
+	}
+
+	c.valid = true
+	if err = c.init(cfg, negotiated); err != nil {
+		release()
+		c.valid = false
+		return c, connectError(ctx, ConnectStepInit, err)
 	}
 
-	if err = setUTF8Charset(stream); err != nil {
-		return &Conn{conn: stream, valid: false, closed: false}, err
+	if !release() {
+		c.valid = false
+		return c, connectError(ctx, ConnectStepInit, ctx.Err())
 	}
 
-	return &Conn{conn: stream, valid: true, closed: false}, nil
+	return c, nil
 }
 
 // Close closes the connection
 func (c *Conn) Close() error {
 	if !c.closed {
 		c.closed = true
+		c.unwatch(nil)
 		return c.conn.Close()
 	}
 	return nil
 }
 
+// Valid reports whether the connection can be reused.
+// Connection becomes invalid when it's closed or when
+// I/O error breaks the stream of packets.
+func (c *Conn) Valid() bool {
+	return c.valid && !c.closed
+}
+
+// Ping checks that the connection is alive by sending COM_PING.
+// If the server doesn't respond, the connection becomes invalid.
+func (c *Conn) Ping() error {
+	c.buf = startPacket(c.buf, comPing)
+	req, _ := finishPacket(c.buf, 0)
+	_, err := c.exec(req)
+	return err
+}
+
 // Stats returns statistics about the connection
+// since it was established or returned to the pool
 func (c *Conn) Stats() Stats {
 	return Stats{
-		Syscalls: c.conn.Syscalls(),
+		Syscalls:     c.conn.Syscalls(),
+		BytesRead:    c.meter.read,
+		BytesWritten: c.meter.written,
 	}
 }
 
 // Add sum ups all stats
 func (s Stats) Add(stats Stats) Stats {
 	return Stats{
-		Syscalls: s.Syscalls + stats.Syscalls,
+		Syscalls:     s.Syscalls + stats.Syscalls,
+		BytesRead:    s.BytesRead + stats.BytesRead,
+		BytesWritten: s.BytesWritten + stats.BytesWritten,
 	}
 }
 
-func setUTF8Charset(conn mysqlproto.Conn) error {
-	data := mysqlproto.ComQueryRequest([]byte("SET NAMES utf8"))
-	if _, err := conn.Write(data); err != nil {
-		return err
+// init sets charset of the connection unless it's negotiated
+// in the handshake and executes init queries
+func (c *Conn) init(cfg *Config, negotiated bool) error {
+	if !negotiated {
+		if err := c.setCharset(c.charset, cfg.Collation); err != nil {
+			return err
+		}
+	}
+	for _, sql := range cfg.InitSQL {
+		if _, err := c.Exec(sql); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	packet, err := conn.NextPacket()
-	if err != nil {
-		return err
+func (c *Conn) setCharset(charset, collation string) error {
+	sql := "SET NAMES " + charset
+	if collation != "" {
+		sql += " COLLATE " + collation
 	}
+	_, err := c.Exec(sql)
+	return err
+}
+
+// Charset returns charset of the connection
+func (c *Conn) Charset() string {
+	return c.charset
+}
 
-	return handleOK(packet.Payload, conn.CapabilityFlags)
+// SetTranscodeLatin1 enables conversion of values of latin1 columns
+// into UTF-8 by String and NullString functions of Rows and Row.
+// It's useful when charset of the connection is latin1,
+// otherwise server converts values by itself.
+func (c *Conn) SetTranscodeLatin1(enabled bool) {
+	c.transcodeLatin1 = enabled
 }
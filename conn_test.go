@@ -2,6 +2,7 @@ package mysqldriver
 
 import (
 	"context"
+	"net"
 	"testing"
 	"time"
 
@@ -37,7 +38,53 @@ func TestNewConnContextCanceled(t *testing.T) {
 	cancel()
 
 	_, err := NewConnContext(ctx, "root", "", "tcp", "127.0.0.1:3306", "test", time.Duration(0))
-	assert.EqualError(t, err, "dial tcp 127.0.0.1:3306: operation was canceled")
+	connErr, ok := err.(*ConnectError)
+	assert.True(t, ok)
+	assert.Equal(t, connErr.Step, ConnectStepDial)
+	assert.Equal(t, connErr.Err, context.Canceled)
+	assert.EqualError(t, connErr.Cause, "dial tcp 127.0.0.1:3306: operation was canceled")
+}
+
+func TestNewConnContextAuthTimeout(t *testing.T) {
+	// server accepts the connection but never sends the greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	conn, err := NewConnContext(ctx, "root", "", "tcp", listener.Addr().String(), "test", time.Duration(0))
+	connErr, ok := err.(*ConnectError)
+	assert.True(t, ok)
+	assert.Equal(t, connErr.Step, ConnectStepAuth)
+	assert.Equal(t, connErr.Err, context.DeadlineExceeded)
+	assert.False(t, conn.valid)
+}
+
+func TestDBOnDialTimeout(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 1, time.Duration(0))
+	db.OnDial = func(conn *Conn) error {
+		_, err := conn.Exec("DO SLEEP(5)")
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	conn, err := db.dial(ctx)
+	connErr, ok := err.(*ConnectError)
+	assert.True(t, ok)
+	assert.Equal(t, connErr.Step, ConnectStepInit)
+	assert.Equal(t, connErr.Err, context.DeadlineExceeded)
+	assert.False(t, conn.valid)
 }
 
 func TestConnClose(t *testing.T) {
@@ -46,3 +93,32 @@ func TestConnClose(t *testing.T) {
 	assert.Nil(t, conn.Close())
 	assert.True(t, conn.closed)
 }
+
+func TestConnPing(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+
+	conn, err := NewConn("root", "", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	assert.NoError(t, conn.Ping())
+	assert.Equal(t, server.receivedCommands(), []byte{comPing})
+	assert.True(t, conn.valid)
+
+	server.dropConns()
+	assert.Error(t, conn.Ping())
+	assert.False(t, conn.valid)
+}
+
+func TestConnPingContextCanceled(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+
+	conn, err := NewConn("root", "", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, conn.PingContext(ctx), context.Canceled)
+	assert.True(t, conn.valid)
+	assert.NoError(t, conn.PingContext(context.Background()))
+}
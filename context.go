@@ -0,0 +1,286 @@
+package mysqldriver
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// aLongTimeAgo is used as a deadline to interrupt blocked I/O immediately
+var aLongTimeAgo = time.Unix(1, 0)
+
+// watcher interrupts the query when its context is done
+type watcher struct {
+	ctx       context.Context
+	finished  chan struct{} // closed when the query is completed
+	exited    chan struct{} // closed when the watching goroutine returns
+	abandoned bool          // connection was interrupted without KILL QUERY
+}
+
+// QueryContext is the same as func (*Conn) Query, but the query
+// is bound to the context until all rows are read.
+// Deadline of the context is applied to the socket. When the context
+// is canceled, the query is stopped on the server with KILL QUERY command
+// sent over a new connection dialed outside of the pool. It's counted
+// by DBStats.Dials, but it isn't an open connection of the pool.
+// In this case, the connection stays valid and error of the context
+// is returned.
+// If the query can't be stopped, the connection becomes invalid
+// and won't be reused by the pool.
+func (c *Conn) QueryContext(ctx context.Context, sql string) (*Rows, error) {
+	return c.queryContext(ctx, sql, nil)
+}
+
+// queryContext performs the query bound to the context.
+// Placeholders are substituted only when arguments are given.
+func (c *Conn) queryContext(ctx context.Context, sql string, args []interface{}) (*Rows, error) {
+	if err := c.watch(ctx); err != nil {
+		return nil, err
+	}
+
+	trace := c.startQuery(ctx, sql, args)
+	req, err := c.request(sql, args)
+	if err != nil {
+		return trace.attach(c, nil, c.unwatch(err))
+	}
+	rows, err := c.query(req)
+	if err != nil {
+		return trace.attach(c, nil, c.queryError(sql, c.unwatch(err)))
+	}
+
+	rows.watched = c.watcher != nil
+	return trace.attach(c, rows, nil)
+}
+
+// ExecContext is the same as func (*Conn) Exec, but the query
+// is bound to the context (see func (*Conn) QueryContext)
+func (c *Conn) ExecContext(ctx context.Context, sql string) (mysqlproto.OKPacket, error) {
+	return c.execContext(ctx, sql, nil)
+}
+
+// execContext executes the query bound to the context.
+// Placeholders are substituted only when arguments are given.
+func (c *Conn) execContext(ctx context.Context, sql string, args []interface{}) (mysqlproto.OKPacket, error) {
+	if err := c.watch(ctx); err != nil {
+		return mysqlproto.OKPacket{}, err
+	}
+
+	trace := c.startQuery(ctx, sql, args)
+	req, err := c.request(sql, args)
+	if err != nil {
+		err = c.unwatch(err)
+		trace.end(c, err)
+		return mysqlproto.OKPacket{}, err
+	}
+	pkt, err := c.exec(req)
+	err = c.queryError(sql, c.unwatch(err))
+	trace.endExec(c, pkt, err)
+	return pkt, err
+}
+
+// QueryArgsContext is the same as func (*Conn) QueryArgs, but the query
+// is bound to the context (see func (*Conn) QueryContext). Placeholders
+// are substituted only when arguments are given.
+func (c *Conn) QueryArgsContext(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	return c.queryContext(ctx, sql, args)
+}
+
+// ExecArgsContext is the same as func (*Conn) ExecArgs, but the query
+// is bound to the context (see func (*Conn) QueryContext). Placeholders
+// are substituted only when arguments are given.
+func (c *Conn) ExecArgsContext(ctx context.Context, sql string, args ...interface{}) (mysqlproto.OKPacket, error) {
+	return c.execContext(ctx, sql, args)
+}
+
+// PingContext is the same as func (*Conn) Ping, but it's bound
+// to the context. When the context is done before the server
+// responds, the connection becomes invalid and error of the context
+// is returned.
+func (c *Conn) PingContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	release := interruptible(ctx, c.netConn)
+	err := c.Ping()
+	if !release() {
+		c.valid = false
+		return ctx.Err()
+	}
+	return err
+}
+
+// watch binds following command to the context
+func (c *Conn) watch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := ctx.Done()
+	if done == nil {
+		return nil // context can't be canceled
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.netConn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	w := &watcher{
+		ctx:      ctx,
+		finished: make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	c.watcher = w
+
+	go func() {
+		defer close(w.exited)
+		select {
+		case <-done:
+			if !c.killQuery() {
+				w.abandoned = true
+				c.netConn.SetDeadline(aLongTimeAgo)
+			}
+		case <-w.finished:
+		}
+	}()
+
+	return nil
+}
+
+// unwatch releases the connection from the context. It waits until
+// KILL QUERY is completed, so it can't affect the following queries.
+// When the command failed because of the context, error of the context
+// is returned.
+func (c *Conn) unwatch(err error) error {
+	w := c.watcher
+	if w == nil {
+		return err
+	}
+	c.watcher = nil
+
+	close(w.finished)
+	<-w.exited
+
+	if w.abandoned {
+		c.valid = false
+	} else if !c.closed {
+		c.netConn.SetDeadline(time.Time{})
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(mysqlproto.ERRPacket); !ok {
+		c.valid = false
+	}
+
+	if ctxErr := w.ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	// socket deadline may expire earlier than the context
+	if deadline, ok := w.ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// interruptible applies deadline of the context to the connection
+// and interrupts its I/O when the context is canceled.
+// Returned function releases the connection from the context.
+// It returns false if the connection was interrupted.
+func interruptible(ctx context.Context, conn net.Conn) func() bool {
+	done := ctx.Done()
+	if done == nil {
+		return func() bool { return true }
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	finished := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-done:
+			conn.SetDeadline(aLongTimeAgo)
+			interrupted <- true
+		case <-finished:
+			interrupted <- false
+		}
+	}()
+
+	return func() bool {
+		close(finished)
+		if <-interrupted {
+			return false
+		}
+		conn.SetDeadline(time.Time{})
+		return true
+	}
+}
+
+// defaultKillTimeout limits establishing of the connection sending
+// KILL QUERY and its execution when Config.Timeout isn't set
+const defaultKillTimeout = 5 * time.Second
+
+// killQuery stops the current query of the connection using another
+// connection. It's dialed outside of the pool, so it doesn't wait
+// for a free slot which may be taken by the connection itself.
+func (c *Conn) killQuery() bool {
+	db := c.db
+	if db == nil || c.connectionID == 0 {
+		return false
+	}
+
+	config, err := db.tlsConfig()
+	if err != nil {
+		return false
+	}
+
+	timeout := db.config.Timeout
+	if timeout == 0 {
+		timeout = defaultKillTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	db.stats.inc(&db.stats.dials)
+	conn, err := connect(ctx, &db.config, config)
+	if err != nil {
+		db.stats.inc(&db.stats.dialErrors)
+		if conn != nil {
+			conn.Close()
+		}
+		return false
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "KILL QUERY "+strconv.FormatUint(uint64(c.connectionID), 10))
+	return err == nil
+}
+
+// finish releases the connection from the context
+// once all rows are read and completes the trace of the query.
+// Connection of func (*DB) Query is returned to the pool.
+func (r *Rows) finish() {
+	if r.watched {
+		r.watched = false
+		r.errRead = r.conn.unwatch(r.errRead)
+	}
+	if r.trace != nil {
+		trace := r.trace
+		r.trace = nil
+		trace.end(r.conn, r.errRead)
+	}
+	if r.db != nil {
+		db := r.db
+		r.db = nil
+		db.PutConn(r.conn)
+	}
+}
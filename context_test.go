@@ -0,0 +1,154 @@
+package mysqldriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryContextSuccess(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 2, time.Duration(0))
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	rows, err := conn.QueryContext(ctx, "SELECT 1")
+	assert.NoError(t, err)
+	assert.NotNil(t, conn.watcher)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.Int(), 1)
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.LastError())
+	assert.Nil(t, conn.watcher)
+	assert.True(t, conn.valid)
+	assert.True(t, conn.connectionID > 0)
+}
+
+func TestQueryContextCanceledBeforeQuery(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 2, time.Duration(0))
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = conn.QueryContext(ctx, "SELECT 1")
+	assert.Equal(t, err, context.Canceled)
+	_, err = conn.ExecContext(ctx, "DO 1")
+	assert.Equal(t, err, context.Canceled)
+	assert.True(t, conn.valid)
+}
+
+func TestExecContextKillsQueryOnCancel(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 2, time.Duration(0))
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = conn.ExecContext(ctx, "DO SLEEP(5)")
+	assert.Equal(t, err, context.Canceled)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	// connection is recovered after KILL QUERY
+	assert.True(t, conn.valid)
+	rows, err := conn.Query("SELECT 2")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.Int(), 2)
+	assert.False(t, rows.Next())
+}
+
+func TestQueryContextKillsQueryOnCancel(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 2, time.Duration(0))
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	rows, err := conn.QueryContext(ctx, "SELECT SLEEP(5)")
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.LastError()
+	}
+	assert.Equal(t, err, context.Canceled)
+	assert.Nil(t, conn.watcher)
+}
+
+func TestExecContextDeadlineWithoutPool(t *testing.T) {
+	conn, err := NewConn("root", "", "tcp", "127.0.0.1:3306", "test", time.Duration(0))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = conn.ExecContext(ctx, "DO SLEEP(5)")
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.False(t, conn.valid)
+}
+
+func TestDBPutConnClosesConnectionWithUnreadContextRows(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 2, time.Duration(0))
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err = conn.QueryContext(ctx, "SELECT 1")
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	assert.True(t, conn.closed)
+	assert.Nil(t, conn.watcher)
+	assert.Len(t, db.conns, 0)
+}
+
+func TestExecContextKillsQueryInFullPool(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 1
+		cfg.MaxOpen = 1
+	})
+	defer server.close()
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.ExecContext(ctx, stalledQuery)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("query isn't killed while the only slot of the pool is taken")
+	}
+	assert.Equal(t, err, context.Canceled)
+
+	// KILL QUERY connection doesn't belong to the pool
+	assert.True(t, conn.valid)
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	assert.Equal(t, db.open, 1)
+	stats := db.Stats()
+	assert.Equal(t, stats.Dials, int64(2))
+	assert.Equal(t, stats.OpenConnections, 1)
+}
@@ -1,8 +1,10 @@
 package mysqldriver
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
-	"strings"
+	"sync"
 	"time"
 )
 
@@ -10,34 +12,74 @@ var ErrClosedDB = errors.New("mysqldriver: can't get connection from the closed
 
 // DB manages pool of connection
 type DB struct {
-	OnDial func(conn *Conn) error // called when new connection is established
-
-	conns    chan *Conn
-	username string
-	password string
-	protocol string
-	address  string
-	database string
-	readTimeout time.Duration
+	OnDial    func(conn *Conn) error // called when new connection is established
+	TLSConfig *tls.Config            // encrypts new connections, overrides "tls" parameter of data source
+	QueryHook QueryHook              // observes queries of the connections, nil by default
+
+	SlowQueryThreshold time.Duration // queries lasting longer are logged by SlowQueryLogger
+	SlowQueryLogger    Logger        // logs slow queries, nil disables the log (see func NormalizeSQL)
+
+	conns  chan *Conn
+	config Config
+	err    error // error of parsing the data source
+
+	mu      sync.Mutex
+	closed  bool
+	open    int                // number of open connections of the bounded pool
+	waiters []chan connRequest // FIFO queue of GetConnContext calls waiting for a connection
+
+	reaperOnce sync.Once
+	stopReaper context.CancelFunc
+	reaperDone chan struct{}
+
+	stats dbStats // see func (*DB) Stats
 }
 
 // NewDB initializes pool of connections but doesn't
 // establishes connection to DB.
 //
 // Pool size is fixed and can't be resized later.
-// DataSource parameter has the following format:
-// [username[:password]@][protocol[(address)]]/dbname
+// DataSource parameter has the format described in func ParseDSN.
+// Pool size given as an argument overrides "pool" parameter
+// of data source and so does non-zero read timeout.
+// If data source is malformed, the error is returned by GetConn.
 func NewDB(dataSource string, pool int, readTimeout time.Duration) *DB {
-	usr, pass, proto, addr, dbname := parseDataSource(dataSource)
-	conns := make(chan *Conn, pool)
+	cfg, err := ParseDSN(dataSource)
+	if err != nil {
+		return &DB{conns: make(chan *Conn, pool), err: err}
+	}
+	cfg.Pool = pool
+	if readTimeout != 0 {
+		cfg.ReadTimeout = readTimeout
+	}
+	return NewDBFromConfig(cfg)
+}
+
+// NewDBFromConfig initializes pool of cfg.Pool connections
+// but doesn't establishes connection to DB. Zero cfg.Pool means
+// 10 connections. The config is validated the same way as by
+// func ParseDSN, so TLS config named by cfg.TLS must be registered
+// beforehand. If it's invalid, the error is returned by GetConn.
+//
+// When cfg.MinIdle, cfg.MaxIdleTime or cfg.MaxLifetime is set,
+// the first call of GetConn starts a background goroutine which
+// closes stale idle connections and establishes new ones.
+// It's stopped by func (*DB) Close.
+//  cfg, err := mysqldriver.ParseDSN("root@tcp(127.0.0.1:3306)/test?pool=10")
+//  if err != nil {
+//  	// handle error
+//  }
+//  cfg.InitSQL = append(cfg.InitSQL, "SET time_zone = '+00:00'")
+//  db := mysqldriver.NewDBFromConfig(cfg)
+func NewDBFromConfig(cfg *Config) *DB {
+	config := *cfg
+	if err := config.normalize(); err != nil {
+		return &DB{conns: make(chan *Conn), err: err}
+	}
 	return &DB{
-		conns:    conns,
-		username: usr,
-		password: pass,
-		protocol: proto,
-		address:  addr,
-		database: dbname,
-		readTimeout: readTimeout,
+		TLSConfig: cfg.TLSConfig,
+		conns:     make(chan *Conn, config.Pool),
+		config:    config,
 	}
 }
 
@@ -45,45 +87,128 @@ func NewDB(dataSource string, pool int, readTimeout time.Duration) *DB {
 // establishes a new one.This method always returns the connection
 // regardless the pool size. When DB is closed, this method
 // returns ErrClosedDB error.
+//
+// When number of open connections is limited by Config.MaxOpen,
+// GetConn waits until another connection is returned to the pool
+// (see func (*DB) GetConnContext).
 func (db *DB) GetConn() (*Conn, error) {
-	select {
-	case conn, more := <-db.conns:
-		if !more {
-			return nil, ErrClosedDB
+	return db.GetConnContext(context.Background())
+}
+
+// GetConnContext is the same as func (*DB) GetConn, but establishing
+// of the connection is bound to the context (see func NewConnContext).
+//
+// When Config.PingIdle is set, connections idle for longer are pinged
+// before they're returned (see func (*Conn) PingContext). Dead ones are
+// discarded and replaced by other idle or new connections.
+//
+// When number of open connections is limited by Config.MaxOpen
+// and the limit is reached, GetConnContext waits for a connection
+// returned to the pool until the context is done. Waiting calls
+// get connections in the same order as they were called.
+func (db *DB) GetConnContext(ctx context.Context) (*Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if db.config.reaped() {
+		db.reaperOnce.Do(db.startReaper)
+	}
+
+	for {
+		select {
+		case conn, more := <-db.conns:
+			if !more {
+				return nil, ErrClosedDB
+			}
+			now := time.Now()
+			if db.stale(conn, now) {
+				db.discard(conn)
+				continue
+			}
+			if db.config.PingIdle > 0 && now.Sub(conn.idleSince) >= db.config.PingIdle {
+				if err := conn.PingContext(ctx); err != nil {
+					db.stats.inc(&db.stats.invalidClosed)
+					db.discard(conn)
+					if ctx.Err() != nil {
+						return nil, ctx.Err()
+					}
+					continue
+				}
+			}
+			return conn, nil
+		default:
 		}
-		return conn, nil
-	default:
-		return db.dial()
+		break
+	}
+
+	if db.config.MaxOpen > 0 {
+		return db.acquire(ctx)
 	}
+	return db.dial(ctx)
 }
 
 // PutConn returns connection to the pool. When pool is reached,
 // connection is closed and won't be further reused.
 // If connection is already closed, PutConn will discard it
 // so it's safe to return closed connection to the pool.
+// Open transaction of the connection is rolled back. If it fails,
+// connection is closed instead of being returned to the pool.
+//
+// Connections of the bounded pool must be returned with PutConn
+// even if they were closed, otherwise they occupy the limit of
+// open connections.
 func (db *DB) PutConn(conn *Conn) (err error) {
 	defer func() {
 		if e := recover(); e != nil {
-			err = conn.Close()
+			err = db.discard(conn)
 			return
 		}
 	}()
 
 	if !conn.valid {
 		// broken connection shouldn't be in a pool
-		return conn.Close()
+		db.stats.inc(&db.stats.invalidClosed)
+		return db.discard(conn)
 	}
 
 	if conn.closed {
-		return nil
+		return db.discard(conn)
 	}
 
-	conn.conn.ResetStats()
+	if conn.watcher != nil {
+		// rows of the query bound to the context weren't read till the end
+		return db.discard(conn)
+	}
+
+	if conn.moreResults() {
+		// result sets of multi-statement query weren't read till the end
+		return db.discard(conn)
+	}
+
+	if conn.inTransaction() {
+		// dirty connection shouldn't be in a pool
+		if _, err := conn.Exec("ROLLBACK"); err != nil {
+			return db.discard(conn)
+		}
+	}
+
+	db.stats.addConn(conn.Stats())
+	conn.resetStats()
+
+	conn.idleSince = time.Now()
+	if db.stale(conn, conn.idleSince) {
+		return db.discard(conn)
+	}
+
+	if db.handOver(conn) {
+		return nil
+	}
 
 	select {
 	case db.conns <- conn:
 	default:
-		err = conn.Close()
+		err = db.discard(conn)
 	}
 
 	return
@@ -93,12 +218,28 @@ func (db *DB) PutConn(conn *Conn) (err error) {
 // doesn't allow to establish new ones to DB any more.
 // Returns slice of errors if any occurred.
 func (db *DB) Close() []error {
+	db.mu.Lock()
+	db.closed = true
+	waiters := db.waiters
+	db.waiters = nil
+	stopReaper, reaperDone := db.stopReaper, db.reaperDone
+	db.mu.Unlock()
+
+	if stopReaper != nil {
+		stopReaper()
+		<-reaperDone
+	}
+
+	for _, req := range waiters {
+		req <- connRequest{err: ErrClosedDB}
+	}
+
 	close(db.conns)
 	var errors []error
 	for {
 		conn, more := <-db.conns
 		if more {
-			if err := conn.Close(); err != nil {
+			if err := db.discard(conn); err != nil {
 				errors = append(errors, err)
 			}
 		} else {
@@ -108,35 +249,48 @@ func (db *DB) Close() []error {
 	return errors
 }
 
-func (db *DB) dial() (*Conn, error) {
-	conn, err := NewConn(db.username, db.password, db.protocol, db.address, db.database, db.readTimeout)
+func (db *DB) dial(ctx context.Context) (*Conn, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+
+	config, err := db.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	db.stats.inc(&db.stats.dials)
+	conn, err := connect(ctx, &db.config, config)
 	if err != nil {
+		db.stats.inc(&db.stats.dialErrors)
 		return conn, err
 	}
+	conn.db = db
+	conn.createdAt = time.Now()
 	if db.OnDial != nil {
+		release := interruptible(ctx, conn.netConn)
 		err = db.OnDial(conn)
+		if !release() {
+			conn.valid = false
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			db.stats.inc(&db.stats.dialErrors)
+			conn.db = nil // connection doesn't belong to the pool
+			return conn, connectError(ctx, ConnectStepInit, err)
+		}
 	}
-	return conn, err
+	db.stats.addOpen(1)
+	return conn, nil
 }
 
-func parseDataSource(dataSource string) (username, password, protocol, address, database string) {
-	params := strings.Split(dataSource, "@")
-
-	userData := strings.Split(params[0], ":")
-	serverData := strings.Split(params[1], "/")
-
-	username = userData[0]
-	if len(userData) > 1 {
-		password = userData[1]
-	}
-
-	if len(serverData) > 1 {
-		database = serverData[1]
+// tlsConfig returns TLS config of the connections
+// (see DB.TLSConfig and "tls" parameter of data source)
+func (db *DB) tlsConfig() (*tls.Config, error) {
+	if db.TLSConfig != nil {
+		return db.TLSConfig, nil
 	}
-
-	protoHost := strings.Split(serverData[0], "(")
-	protocol = protoHost[0]
-	address = protoHost[1][:len(protoHost[1])-1]
-
-	return
+	return tlsConfigByName(db.config.TLS)
 }
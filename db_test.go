@@ -67,7 +67,7 @@ func TestDBPutConnClosesConnectionWhenItIsInvalid(t *testing.T) {
 	assert.Nil(t, errors)
 
 	s := &stream{}
-	conn := &Conn{mysqlproto.Conn{mysqlproto.NewStream(s, time.Duration(0)), 0}, false, false}
+	conn := &Conn{conn: mysqlproto.Conn{mysqlproto.NewStream(s, time.Duration(0)), 0}, valid: false, closed: false}
 	db.PutConn(conn)
 	assert.True(t, s.closed)
 	assert.Len(t, db.conns, 0)
@@ -89,7 +89,7 @@ func TestDBPutConnClosesConnectionWhenDBIsClosed(t *testing.T) {
 	assert.Nil(t, errors)
 
 	s := &stream{}
-	conn := &Conn{mysqlproto.Conn{mysqlproto.NewStream(s, time.Duration(0)), 0}, true, false}
+	conn := &Conn{conn: mysqlproto.Conn{mysqlproto.NewStream(s, time.Duration(0)), 0}, valid: true, closed: false}
 	db.PutConn(conn)
 	assert.True(t, s.closed)
 	assert.Len(t, db.conns, 0)
@@ -98,10 +98,10 @@ func TestDBPutConnClosesConnectionWhenDBIsClosed(t *testing.T) {
 func TestDBCloseClosesAllConnections(t *testing.T) {
 	db := NewDB("root@tcp(127.0.0.1:3306)/test", 2, time.Duration(0))
 	s1 := &stream{}
-	conn1 := &Conn{mysqlproto.Conn{mysqlproto.NewStream(s1, time.Duration(0)), 0}, true, false}
+	conn1 := &Conn{conn: mysqlproto.Conn{mysqlproto.NewStream(s1, time.Duration(0)), 0}, valid: true, closed: false}
 	db.PutConn(conn1)
 	s2 := &stream{}
-	conn2 := &Conn{mysqlproto.Conn{mysqlproto.NewStream(s2, time.Duration(0)), 0}, true, false}
+	conn2 := &Conn{conn: mysqlproto.Conn{mysqlproto.NewStream(s2, time.Duration(0)), 0}, valid: true, closed: false}
 	db.PutConn(conn2)
 
 	assert.Len(t, db.conns, 2)
@@ -115,36 +115,6 @@ func TestDBCloseClosesAllConnections(t *testing.T) {
 	assert.False(t, more)
 }
 
-func TestParseDataSourceFull(t *testing.T) {
-	source := "root:123@tcp(127.0.0.1:3306)/test"
-	usr, pass, proto, addr, dbname := parseDataSource(source)
-	assert.Equal(t, usr, "root")
-	assert.Equal(t, pass, "123")
-	assert.Equal(t, proto, "tcp")
-	assert.Equal(t, addr, "127.0.0.1:3306")
-	assert.Equal(t, dbname, "test")
-}
-
-func TestParseDataSourceWithoutPassword(t *testing.T) {
-	source := "root@tcp(127.0.0.1:3306)/test"
-	usr, pass, proto, addr, dbname := parseDataSource(source)
-	assert.Equal(t, usr, "root")
-	assert.Equal(t, pass, "")
-	assert.Equal(t, proto, "tcp")
-	assert.Equal(t, addr, "127.0.0.1:3306")
-	assert.Equal(t, dbname, "test")
-}
-
-func TestParseDataSourceWithoutDatabase(t *testing.T) {
-	source := "root@tcp(127.0.0.1:3306)"
-	usr, pass, proto, addr, dbname := parseDataSource(source)
-	assert.Equal(t, usr, "root")
-	assert.Equal(t, pass, "")
-	assert.Equal(t, proto, "tcp")
-	assert.Equal(t, addr, "127.0.0.1:3306")
-	assert.Equal(t, dbname, "")
-}
-
 type stream struct{ closed bool }
 
 func (s *stream) Write([]byte) (int, error) { return 0, nil }
@@ -163,3 +133,50 @@ func (m MockAddr) String() string { return "" }
 func ExampleNewDB() {
 	NewDB("root@tcp(127.0.0.1:3306)/test", 10, time.Duration(0))
 }
+
+func TestNewDBMalformedDataSource(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306/test", 1, time.Duration(0))
+	_, err := db.GetConn()
+	assert.EqualError(t, err, "mysqldriver: invalid DSN: unclosed address")
+}
+
+func TestNewDBOverridesDataSourceParams(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test?pool=5&readTimeout=5s", 2, time.Duration(0))
+	assert.Equal(t, cap(db.conns), 2)
+	assert.Equal(t, db.config.ReadTimeout, 5*time.Second)
+
+	db = NewDB("root@tcp(127.0.0.1:3306)/test?readTimeout=5s", 2, time.Second)
+	assert.Equal(t, db.config.ReadTimeout, time.Second)
+}
+
+func TestNewDBFromConfigDefaults(t *testing.T) {
+	db := NewDBFromConfig(&Config{User: "root"})
+	assert.Equal(t, cap(db.conns), defaultPool)
+	assert.Equal(t, db.config.Net, "tcp")
+	assert.Equal(t, db.config.Addr, defaultTCPAddr)
+}
+
+func TestNewDBFromConfigInvalid(t *testing.T) {
+	cases := map[string]*Config{
+		"mysqldriver: invalid config: negative number of connections or retries": {Pool: -1},
+		"mysqldriver: invalid config: negative duration":                         {Timeout: -time.Second},
+		`mysqldriver: invalid config: charset "utf8;--"`:                         {Charset: "utf8;--"},
+		`mysqldriver: unknown TLS config "unknown"`:                              {TLS: "unknown"},
+	}
+	for msg, cfg := range cases {
+		_, err := NewDBFromConfig(cfg).GetConn()
+		assert.EqualError(t, err, msg)
+	}
+}
+
+func TestDBInitSQL(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test?init=SET+%40answer+%3D+42", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	rows, err := conn.Query("SELECT @answer")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.Int(), 42)
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.LastError())
+}
@@ -20,6 +20,33 @@ when it's not needed for further reuse.
  	}()
  }
 
+By default the pool establishes a new connection whenever there is
+no idle one. Number of open connections can be limited with "maxOpen"
+parameter of data source, then GetConnContext waits for a connection
+returned to the pool until the context is done.
+
+ db := mysqldriver.NewDB("root@tcp(127.0.0.1:3306)/test?maxOpen=20", 10, 0)
+ ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+ defer cancel()
+ conn, err := db.GetConnContext(ctx)
+ if err != nil {
+ 	// handle error
+ }
+ defer db.PutConn(conn) // must be returned even if it's closed
+
+Idle connections may be closed by the server after wait_timeout
+or by a load balancer. Parameters "maxIdleTime" and "maxLifetime"
+make the pool close such connections before they break, "minIdle"
+keeps idle connections established in advance. These parameters
+start a background goroutine, so the DB must be closed when it isn't
+needed any more.
+Parameter "pingIdle" makes GetConn check connections which were idle
+for the given time with COM_PING, dead ones are replaced transparently.
+DB.Query and DB.Exec get the connection from the pool and retry the
+query with another connection when the first one turns out to be broken.
+Number of retries and the delay between them are set by "maxRetries"
+and "retryBackoff" parameters.
+
 Reading rows
 
 mysqldriver reads data from the DB in a sequential order
@@ -46,6 +73,23 @@ with the number of columns in a query.
   	conn.Close()
  }
 
+Values of the row can be read into variables with Scan or into
+fields of the struct with ScanStruct. Columns are matched with
+fields by "mysql" tag or by the name of the field.
+
+ type Person struct {
+ 	ID      int64  `mysql:"id"`
+ 	Name    string `mysql:"name"`
+ 	Married bool   `mysql:"married"`
+ }
+
+ for rows.Next() {
+ 	var p Person
+ 	if err := rows.ScanStruct(&p); err != nil {
+ 		// handle error
+ 	}
+ }
+
 When there is no need to read the whole result set, for instance
 when error occurred during parsing data, connection must be closed
 to prevent further reuse as it's in invalid state.
@@ -73,5 +117,123 @@ to prevent further reuse as it's in invalid state.
  	// it won't be reused by the pool.
  	conn.Close()
  }
+
+Errors
+
+Queries which fail on the server or break the connection return *Error
+holding the query. Errors of the server are wrapped mysqlproto.ERRPacket
+values, so they should be matched with errors.As instead of
+the type assertion. Classes of errors such as ErrDuplicateKey
+are matched with errors.Is.
+
+ _, err := conn.Exec("INSERT INTO dogs(id, name) VALUES (1, 'Rex')")
+ var errPacket mysqlproto.ERRPacket
+ if errors.As(err, &errPacket) {
+ 	errPacket.ErrorCode // 1062
+ }
+ if errors.Is(err, mysqldriver.ErrConnectionLost) {
+ 	// query may be applied, connection is discarded by the pool
+ }
+
+Multiple statements
+
+Parameter "multiStatements" allows multiple statements separated
+by ";" in one query. Result sets of such query are read one after
+another with NextResultSet, ExecMulti returns OK_PACKET of every
+statement. Since queries with arguments are interpolated on the
+client side, the option doesn't make them more prone to injections,
+but it's still advised to keep it disabled when it isn't needed.
+
+ rows, err := conn.Query("SELECT name FROM dogs; SELECT name FROM cats")
+ if err != nil {
+ 	// handle error
+ }
+ for rows.Next() {
+ 	rows.String() // dog's name
+ }
+ if rows.NextResultSet() {
+ 	for rows.Next() {
+ 		rows.String() // cat's name
+ 	}
+ }
+
+Prepared statements
+
+Statements prepared on the server are bound to the connection
+which prepared them. They become invalid as soon as the connection
+is closed or discarded by the pool.
+
+ stmt, err := conn.Prepare("SELECT name FROM people WHERE age > ?")
+ if err != nil {
+ 	// handle error
+ }
+ defer stmt.Close()
+
+ rows, err := stmt.Query(18) // arguments are sent with the binary protocol
+ if err != nil {
+ 	// handle error
+ }
+ for rows.Next() {
+ 	name := rows.String()
+ }
+
+Stored procedures
+
+Call executes the stored procedure as a prepared statement, so its
+result sets are read with Next and NextResultSet. OUT and INOUT
+parameters are passed with Out and set once all result sets are read.
+
+ var total int64
+ rows, err := conn.Call("count_dogs", "Rex", mysqldriver.Out{Dest: &total})
+ if err != nil {
+ 	// handle error
+ }
+ for rows.NextResultSet() {
+ 	// skip result sets
+ }
+ if err = rows.LastError(); err != nil {
+ 	// handle error
+ }
+
+Loading data
+
+LoadData streams io.Reader to the server with LOAD DATA LOCAL INFILE
+statement, which is much faster than inserting rows one by one.
+Server may request any file from the client, so files named by
+LOAD DATA LOCAL INFILE statements are sent only when they're
+registered with RegisterLocalFile.
+
+ pkt, err := conn.LoadData("LOAD DATA LOCAL INFILE 'dogs' INTO TABLE dogs", reader)
+ if err != nil {
+ 	// handle error
+ }
+ pkt.AffectedRows // number of loaded rows
+
+Bulk insert
+
+BulkInserter builds multi-row INSERT statements from the appended values
+and executes them whenever the next row wouldn't fit into max_allowed_packet.
+
+ inserter, err := conn.BulkInsert("dogs", []string{"name", "age"}, mysqldriver.BulkInsertOptions{})
+ if err != nil {
+ 	// handle error
+ }
+ inserter.AppendString("Rex")
+ inserter.AppendInt(3)
+ pkt, err := inserter.Flush()
+
+TLS
+
+Connections are encrypted with TLS when "tls" parameter of data source
+is set to "true", "skip-verify" or name of the config registered
+with RegisterTLSConfig. DB.TLSConfig can be set directly as well.
+
+ db := mysqldriver.NewDB("root@tcp(127.0.0.1:3306)/test?tls=true", 10, 0)
+
+Users authenticated by caching_sha2_password or sha256_password send
+the password over TCP connection without TLS encrypted with public key
+of the server. The key isn't requested from the server unless
+"allowPublicKeyRetrieval" parameter is set, because it may be
+substituted by the man in the middle.
 */
 package mysqldriver
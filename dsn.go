@@ -0,0 +1,396 @@
+package mysqldriver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTCPAddr  = "127.0.0.1:3306"
+	defaultUnixAddr = "/tmp/mysql.sock"
+	defaultPort     = "3306"
+	defaultPool     = 10 // idle connections kept by the pool when Config.Pool isn't set
+)
+
+// Config holds settings of connections to the DB
+// which can be parsed from the data source (see func ParseDSN)
+type Config struct {
+	User     string
+	Password string
+	Net      string // "tcp", "tcp4", "tcp6" or "unix"
+	Addr     string // host:port or path to unix socket
+	DBName   string
+
+	Pool        int           // number of idle connections kept by the pool
+	MaxOpen     int           // limit of open connections of the pool, 0 means unlimited
+	MinIdle     int           // number of idle connections established in advance, limited by Pool
+	MaxIdleTime time.Duration // idle connections are closed after this time, 0 means forever
+	MaxLifetime time.Duration // connections are closed after this time since they're established, 0 means forever
+	PingIdle    time.Duration // connections idle for this time are pinged by GetConn, 0 disables pings
+	Timeout     time.Duration // timeout of establishing the connection
+	ReadTimeout time.Duration // timeout of reading a packet
+	Charset     string        // charset of the connection
+	Collation   string        // collation of the connection
+	TLS         string        // "true", "false", "skip-verify" or name of registered TLS config
+	TLSConfig   *tls.Config   // overrides TLS, it isn't a part of data source
+	InitSQL     []string      // queries executed on every new connection
+
+	MaxRetries   int           // retries of DB.Query and DB.Exec, 0 disables retries
+	RetryBackoff time.Duration // delay before the first retry, doubled by every next one
+
+	TranscodeLatin1 bool // see func (*Conn) SetTranscodeLatin1
+	MultiStatements bool // allows multiple statements in one query (see func (*Rows) NextResultSet)
+
+	// AllowPublicKeyRetrieval allows requesting RSA public key of the server
+	// to encrypt the password when the connection isn't secured with TLS.
+	// The key may be forged by the man in the middle, so it's refused by default.
+	AllowPublicKeyRetrieval bool
+}
+
+// ParseDSN parses the data source which has the following format:
+//  [username[:password]@][protocol[(address)]][/dbname][?param1=value1&paramN=valueN]
+// Username, password and database name may be URL-escaped, so they can
+// contain reserved characters like "@" or "/". Values with invalid
+// escapes, e.g. password "100%", are kept as is, but "%" followed
+// by two hex digits must be escaped as "%25". IPv6 address must be
+// enclosed in square brackets. Default address is 127.0.0.1:3306
+// for TCP and /tmp/mysql.sock for unix sockets.
+//
+// Supported parameters:
+//  pool=<int>                          number of idle connections kept by the pool
+//  maxOpen=<int>                       limit of open connections of the pool (see func (*DB) GetConnContext)
+//  minIdle=<int>                       number of idle connections established in advance
+//  maxIdleTime=<duration>              idle connections are closed after this time
+//  maxLifetime=<duration>              connections are closed after this time since they're established
+//  pingIdle=<duration>                 connections idle for this time are pinged before reuse
+//  maxRetries=<int>                    retries of DB.Query and DB.Exec (see func (*DB) Query)
+//  retryBackoff=<duration>             delay before the first retry, doubled by every next one
+//  timeout=<duration>                  timeout of establishing the connection, e.g. 5s
+//  readTimeout=<duration>              timeout of reading a packet
+//  charset=<name>                      charset of the connection, utf8mb4 by default
+//  collation=<name>                    collation of the connection
+//  transcodeLatin1=<bool>              converts latin1 values into UTF-8 strings
+//  multiStatements=<bool>              allows multiple statements separated by ";" in one query
+//  tls=true|false|skip-verify|<name>   encrypts connections with TLS (see func RegisterTLSConfig)
+//  allowPublicKeyRetrieval=<bool>      allows requesting public key of the server without TLS
+//  init=<sql>                          query executed on every new connection, may be repeated
+func ParseDSN(dsn string) (*Config, error) {
+	cfg := &Config{}
+
+	var query string
+	if i := strings.IndexByte(dsn, '?'); i >= 0 {
+		dsn, query = dsn[:i], dsn[i+1:]
+	}
+
+	// credentials end with the last "@" before the address
+	end := strings.IndexAny(dsn, "(/")
+	if end < 0 {
+		end = len(dsn)
+	}
+	if at := strings.LastIndexByte(dsn[:end], '@'); at >= 0 {
+		user, password := dsn[:at], ""
+		if colon := strings.IndexByte(user, ':'); colon >= 0 {
+			user, password = user[:colon], user[colon+1:]
+		}
+		cfg.User = unescapeDSN(user)
+		cfg.Password = unescapeDSN(password)
+		dsn = dsn[at+1:]
+	}
+
+	if open := strings.IndexByte(dsn, '('); open >= 0 {
+		closing := strings.IndexByte(dsn[open:], ')')
+		if closing < 0 {
+			return nil, fmt.Errorf("mysqldriver: invalid DSN: unclosed address")
+		}
+		cfg.Net = dsn[:open]
+		cfg.Addr = dsn[open+1 : open+closing]
+		dsn = dsn[open+closing+1:]
+	} else {
+		slash := strings.IndexByte(dsn, '/')
+		if slash < 0 {
+			slash = len(dsn)
+		}
+		cfg.Net = dsn[:slash]
+		dsn = dsn[slash:]
+	}
+
+	if dsn != "" {
+		if dsn[0] != '/' {
+			return nil, fmt.Errorf("mysqldriver: invalid DSN: expected \"/\" before database name")
+		}
+		cfg.DBName = unescapeDSN(dsn[1:])
+	}
+
+	if err := cfg.normalizeAddr(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.parseParams(query); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// FormatDSN returns the data source which is parsed
+// by func ParseDSN into the same config except TLSConfig
+func (cfg *Config) FormatDSN() string {
+	var buf bytes.Buffer
+
+	if cfg.User != "" || cfg.Password != "" {
+		buf.WriteString(escapeDSN(cfg.User))
+		if cfg.Password != "" {
+			buf.WriteByte(':')
+			buf.WriteString(escapeDSN(cfg.Password))
+		}
+		buf.WriteByte('@')
+	}
+
+	buf.WriteString(cfg.Net)
+	if cfg.Addr != "" {
+		buf.WriteByte('(')
+		buf.WriteString(cfg.Addr)
+		buf.WriteByte(')')
+	}
+
+	buf.WriteByte('/')
+	buf.WriteString(escapeDSN(cfg.DBName))
+
+	params := url.Values{}
+	if cfg.Pool != 0 {
+		params.Set("pool", strconv.Itoa(cfg.Pool))
+	}
+	if cfg.MaxOpen != 0 {
+		params.Set("maxOpen", strconv.Itoa(cfg.MaxOpen))
+	}
+	if cfg.MinIdle != 0 {
+		params.Set("minIdle", strconv.Itoa(cfg.MinIdle))
+	}
+	if cfg.MaxIdleTime != 0 {
+		params.Set("maxIdleTime", cfg.MaxIdleTime.String())
+	}
+	if cfg.MaxLifetime != 0 {
+		params.Set("maxLifetime", cfg.MaxLifetime.String())
+	}
+	if cfg.PingIdle != 0 {
+		params.Set("pingIdle", cfg.PingIdle.String())
+	}
+	if cfg.MaxRetries != 0 {
+		params.Set("maxRetries", strconv.Itoa(cfg.MaxRetries))
+	}
+	if cfg.RetryBackoff != 0 {
+		params.Set("retryBackoff", cfg.RetryBackoff.String())
+	}
+	if cfg.Timeout != 0 {
+		params.Set("timeout", cfg.Timeout.String())
+	}
+	if cfg.ReadTimeout != 0 {
+		params.Set("readTimeout", cfg.ReadTimeout.String())
+	}
+	if cfg.Charset != "" {
+		params.Set("charset", cfg.Charset)
+	}
+	if cfg.Collation != "" {
+		params.Set("collation", cfg.Collation)
+	}
+	if cfg.TLS != "" {
+		params.Set("tls", cfg.TLS)
+	}
+	for _, sql := range cfg.InitSQL {
+		params.Add("init", sql)
+	}
+	if cfg.TranscodeLatin1 {
+		params.Set("transcodeLatin1", "true")
+	}
+	if cfg.MultiStatements {
+		params.Set("multiStatements", "true")
+	}
+	if cfg.AllowPublicKeyRetrieval {
+		params.Set("allowPublicKeyRetrieval", "true")
+	}
+	if len(params) > 0 {
+		buf.WriteByte('?')
+		buf.WriteString(params.Encode())
+	}
+
+	return buf.String()
+}
+
+func (cfg *Config) normalizeAddr() error {
+	switch cfg.Net {
+	case "":
+		cfg.Net = "tcp"
+		fallthrough
+	case "tcp", "tcp4", "tcp6":
+		if cfg.Addr == "" {
+			cfg.Addr = defaultTCPAddr
+			return nil
+		}
+		if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+			// port is missing
+			host := strings.TrimSuffix(strings.TrimPrefix(cfg.Addr, "["), "]")
+			cfg.Addr = net.JoinHostPort(host, defaultPort)
+		}
+	case "unix":
+		if cfg.Addr == "" {
+			cfg.Addr = defaultUnixAddr
+		}
+	default:
+		return fmt.Errorf("mysqldriver: invalid DSN: unknown protocol %q", cfg.Net)
+	}
+	return nil
+}
+
+// normalize fills defaults of the config which isn't parsed
+// from the data source and validates it the same way as func ParseDSN does
+func (cfg *Config) normalize() error {
+	if err := cfg.normalizeAddr(); err != nil {
+		return err
+	}
+	if cfg.Pool == 0 {
+		cfg.Pool = defaultPool
+	}
+	if cfg.Pool < 0 || cfg.MaxOpen < 0 || cfg.MinIdle < 0 || cfg.MaxRetries < 0 {
+		return errors.New("mysqldriver: invalid config: negative number of connections or retries")
+	}
+	if cfg.MaxIdleTime < 0 || cfg.MaxLifetime < 0 || cfg.PingIdle < 0 || cfg.RetryBackoff < 0 ||
+		cfg.Timeout < 0 || cfg.ReadTimeout < 0 {
+		return errors.New("mysqldriver: invalid config: negative duration")
+	}
+	if cfg.Charset != "" && !isIdentifier(cfg.Charset) {
+		return fmt.Errorf("mysqldriver: invalid config: charset %q", cfg.Charset)
+	}
+	if cfg.Collation != "" && !isIdentifier(cfg.Collation) {
+		return fmt.Errorf("mysqldriver: invalid config: collation %q", cfg.Collation)
+	}
+	if cfg.TLSConfig == nil {
+		if _, err := tlsConfigByName(cfg.TLS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) parseParams(query string) error {
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("mysqldriver: invalid DSN parameters: %v", err)
+	}
+
+	for key, values := range params {
+		value := values[len(values)-1]
+		switch key {
+		case "pool":
+			if cfg.Pool, err = strconv.Atoi(value); err != nil || cfg.Pool < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter pool=%q", value)
+			}
+		case "maxOpen":
+			if cfg.MaxOpen, err = strconv.Atoi(value); err != nil || cfg.MaxOpen < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter maxOpen=%q", value)
+			}
+		case "minIdle":
+			if cfg.MinIdle, err = strconv.Atoi(value); err != nil || cfg.MinIdle < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter minIdle=%q", value)
+			}
+		case "maxIdleTime":
+			if cfg.MaxIdleTime, err = time.ParseDuration(value); err != nil || cfg.MaxIdleTime < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter maxIdleTime=%q", value)
+			}
+		case "maxLifetime":
+			if cfg.MaxLifetime, err = time.ParseDuration(value); err != nil || cfg.MaxLifetime < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter maxLifetime=%q", value)
+			}
+		case "pingIdle":
+			if cfg.PingIdle, err = time.ParseDuration(value); err != nil || cfg.PingIdle < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter pingIdle=%q", value)
+			}
+		case "maxRetries":
+			if cfg.MaxRetries, err = strconv.Atoi(value); err != nil || cfg.MaxRetries < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter maxRetries=%q", value)
+			}
+		case "retryBackoff":
+			if cfg.RetryBackoff, err = time.ParseDuration(value); err != nil || cfg.RetryBackoff < 0 {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter retryBackoff=%q", value)
+			}
+		case "timeout":
+			if cfg.Timeout, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter timeout=%q", value)
+			}
+		case "readTimeout":
+			if cfg.ReadTimeout, err = time.ParseDuration(value); err != nil {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter readTimeout=%q", value)
+			}
+		case "charset":
+			if !isIdentifier(value) {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter charset=%q", value)
+			}
+			cfg.Charset = value
+		case "collation":
+			if !isIdentifier(value) {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter collation=%q", value)
+			}
+			cfg.Collation = value
+		case "tls":
+			cfg.TLS = value
+		case "init":
+			cfg.InitSQL = values
+		case "transcodeLatin1":
+			if cfg.TranscodeLatin1, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter transcodeLatin1=%q", value)
+			}
+		case "multiStatements":
+			if cfg.MultiStatements, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter multiStatements=%q", value)
+			}
+		case "allowPublicKeyRetrieval":
+			if cfg.AllowPublicKeyRetrieval, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("mysqldriver: invalid DSN parameter allowPublicKeyRetrieval=%q", value)
+			}
+		default:
+			return fmt.Errorf("mysqldriver: unknown DSN parameter %q", key)
+		}
+	}
+
+	return nil
+}
+
+// escapeDSN escapes reserved characters of username, password
+// and database name. Spaces are escaped as %20 instead of "+"
+// which is kept as is by func unescapeDSN.
+func escapeDSN(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// unescapeDSN decodes URL-escaped username, password or database name.
+// Value which isn't escaped properly, e.g. password containing "%",
+// is taken as is, so data sources made before escaping was supported
+// keep working.
+func unescapeDSN(s string) string {
+	unescaped, err := url.PathUnescape(s)
+	if err != nil {
+		return s
+	}
+	return unescaped
+}
+
+// isIdentifier reports whether name of charset or collation
+// can be safely used in SQL
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
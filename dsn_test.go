@@ -0,0 +1,189 @@
+package mysqldriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDSNFull(t *testing.T) {
+	cfg, err := ParseDSN("root:123@tcp(127.0.0.1:3306)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.User, "root")
+	assert.Equal(t, cfg.Password, "123")
+	assert.Equal(t, cfg.Net, "tcp")
+	assert.Equal(t, cfg.Addr, "127.0.0.1:3306")
+	assert.Equal(t, cfg.DBName, "test")
+}
+
+func TestParseDSNWithoutPassword(t *testing.T) {
+	cfg, err := ParseDSN("root@tcp(127.0.0.1:3306)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.User, "root")
+	assert.Equal(t, cfg.Password, "")
+	assert.Equal(t, cfg.Net, "tcp")
+	assert.Equal(t, cfg.Addr, "127.0.0.1:3306")
+	assert.Equal(t, cfg.DBName, "test")
+}
+
+func TestParseDSNWithoutDatabase(t *testing.T) {
+	cfg, err := ParseDSN("root@tcp(127.0.0.1:3306)")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.User, "root")
+	assert.Equal(t, cfg.Password, "")
+	assert.Equal(t, cfg.Net, "tcp")
+	assert.Equal(t, cfg.Addr, "127.0.0.1:3306")
+	assert.Equal(t, cfg.DBName, "")
+}
+
+func TestParseDSNEscapedCredentials(t *testing.T) {
+	cfg, err := ParseDSN("us%40er:p%40ss%3Aw%2Ford+1@tcp(127.0.0.1:3306)/my%2Fdb")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.User, "us@er")
+	assert.Equal(t, cfg.Password, "p@ss:w/ord+1")
+	assert.Equal(t, cfg.DBName, "my/db")
+}
+
+func TestParseDSNUnescapedAt(t *testing.T) {
+	cfg, err := ParseDSN("root:p@ss@tcp(127.0.0.1:3306)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.User, "root")
+	assert.Equal(t, cfg.Password, "p@ss")
+}
+
+func TestParseDSNUnescapedPercent(t *testing.T) {
+	// invalid escapes are kept as is
+	cfg, err := ParseDSN("root:100%pass%zz@tcp(127.0.0.1:3306)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Password, "100%pass%zz")
+}
+
+func TestParseDSNWithoutCredentials(t *testing.T) {
+	cfg, err := ParseDSN("tcp(127.0.0.1:3306)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.User, "")
+	assert.Equal(t, cfg.Addr, "127.0.0.1:3306")
+	assert.Equal(t, cfg.DBName, "test")
+}
+
+func TestParseDSNDefaults(t *testing.T) {
+	cfg, err := ParseDSN("root@/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Net, "tcp")
+	assert.Equal(t, cfg.Addr, "127.0.0.1:3306")
+	assert.Equal(t, cfg.DBName, "test")
+
+	cfg, err = ParseDSN("root@tcp(localhost)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Addr, "localhost:3306")
+
+	cfg, err = ParseDSN("root@unix/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Net, "unix")
+	assert.Equal(t, cfg.Addr, "/tmp/mysql.sock")
+}
+
+func TestParseDSNUnixSocket(t *testing.T) {
+	cfg, err := ParseDSN("root@unix(/var/run/mysqld/mysqld.sock)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Net, "unix")
+	assert.Equal(t, cfg.Addr, "/var/run/mysqld/mysqld.sock")
+	assert.Equal(t, cfg.DBName, "test")
+
+	cfg, err = ParseDSN("root@unix(/var/run/mysqld/mysqld.sock)")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Addr, "/var/run/mysqld/mysqld.sock")
+	assert.Equal(t, cfg.DBName, "")
+}
+
+func TestParseDSNIPv6(t *testing.T) {
+	cfg, err := ParseDSN("root@tcp([::1]:3307)/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Addr, "[::1]:3307")
+
+	cfg, err = ParseDSN("root@tcp([fe80::1])/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Addr, "[fe80::1]:3306")
+}
+
+func TestParseDSNParams(t *testing.T) {
+	cfg, err := ParseDSN("root@tcp(127.0.0.1:3306)/test?pool=10&maxOpen=20&minIdle=2&maxIdleTime=1m&maxLifetime=1h&pingIdle=10s&maxRetries=3&retryBackoff=10ms&timeout=5s&readTimeout=100ms" +
+		"&charset=utf8mb4&collation=utf8mb4_unicode_ci&tls=skip-verify&multiStatements=true&allowPublicKeyRetrieval=true" +
+		"&init=SET+time_zone+%3D+%27%2B00%3A00%27&init=SET+autocommit+%3D+1")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Pool, 10)
+	assert.Equal(t, cfg.MaxOpen, 20)
+	assert.Equal(t, cfg.MinIdle, 2)
+	assert.Equal(t, cfg.MaxIdleTime, time.Minute)
+	assert.Equal(t, cfg.MaxLifetime, time.Hour)
+	assert.Equal(t, cfg.PingIdle, 10*time.Second)
+	assert.Equal(t, cfg.MaxRetries, 3)
+	assert.Equal(t, cfg.RetryBackoff, 10*time.Millisecond)
+	assert.Equal(t, cfg.Timeout, 5*time.Second)
+	assert.Equal(t, cfg.ReadTimeout, 100*time.Millisecond)
+	assert.Equal(t, cfg.Charset, "utf8mb4")
+	assert.Equal(t, cfg.Collation, "utf8mb4_unicode_ci")
+	assert.Equal(t, cfg.TLS, "skip-verify")
+	assert.Equal(t, cfg.InitSQL, []string{"SET time_zone = '+00:00'", "SET autocommit = 1"})
+	assert.True(t, cfg.MultiStatements)
+	assert.True(t, cfg.AllowPublicKeyRetrieval)
+}
+
+func TestParseDSNErrors(t *testing.T) {
+	cases := map[string]string{
+		"root@tcp(127.0.0.1:3306/test":                    "mysqldriver: invalid DSN: unclosed address",
+		"root@tcp(127.0.0.1:3306)test":                    `mysqldriver: invalid DSN: expected "/" before database name`,
+		"root@udp(127.0.0.1:3306)/test":                   `mysqldriver: invalid DSN: unknown protocol "udp"`,
+		"root@tcp(127.0.0.1:3306)/test?pool=x":            `mysqldriver: invalid DSN parameter pool="x"`,
+		"root@tcp(127.0.0.1:3306)/test?timeout=5":         `mysqldriver: invalid DSN parameter timeout="5"`,
+		"root@tcp(127.0.0.1:3306)/test?maxIdleTime=-1s":   `mysqldriver: invalid DSN parameter maxIdleTime="-1s"`,
+		"root@tcp(127.0.0.1:3306)/test?charset=utf8%3B--": `mysqldriver: invalid DSN parameter charset="utf8;--"`,
+		"root@tcp(127.0.0.1:3306)/test?unknown=1":         `mysqldriver: unknown DSN parameter "unknown"`,
+		"root@tcp(127.0.0.1:3306)/test?multiStatements=x": `mysqldriver: invalid DSN parameter multiStatements="x"`,
+	}
+	for dsn, msg := range cases {
+		_, err := ParseDSN(dsn)
+		assert.EqualError(t, err, msg)
+	}
+}
+
+func TestFormatDSNRoundTrip(t *testing.T) {
+	cfg := &Config{
+		User:        "us@er",
+		Password:    "p@ss:w/ord+1 ?",
+		Net:         "tcp",
+		Addr:        "[::1]:3306",
+		DBName:      "my db",
+		Pool:        10,
+		MaxOpen:     20,
+		MinIdle:     2,
+		MaxIdleTime: time.Minute,
+		MaxLifetime: time.Hour,
+		PingIdle:    10 * time.Second,
+		Timeout:     5 * time.Second,
+		ReadTimeout: 100 * time.Millisecond,
+		Charset:     "utf8mb4",
+		Collation:   "utf8mb4_unicode_ci",
+		TLS:         "custom",
+		InitSQL:     []string{"SET time_zone = '+00:00'", "SET autocommit = 1"},
+
+		MaxRetries:   3,
+		RetryBackoff: 10 * time.Millisecond,
+
+		TranscodeLatin1: true,
+		MultiStatements: true,
+
+		AllowPublicKeyRetrieval: true,
+	}
+
+	parsed, err := ParseDSN(cfg.FormatDSN())
+	assert.NoError(t, err)
+	assert.Equal(t, parsed, cfg)
+}
+
+func TestFormatDSN(t *testing.T) {
+	cfg, err := ParseDSN("root:123@/test")
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.FormatDSN(), "root:123@tcp(127.0.0.1:3306)/test")
+}
@@ -0,0 +1,166 @@
+package mysqldriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// Classes of errors. Errors returned by queries
+// can be matched with them using errors.Is.
+//  _, err := conn.Exec("INSERT INTO dogs(id, name) VALUES (1, 'Rex')")
+//  if errors.Is(err, mysqldriver.ErrDuplicateKey) {
+//  	// dog already exists
+//  }
+var (
+	ErrDuplicateKey    = errors.New("mysqldriver: duplicate key")
+	ErrDeadlock        = errors.New("mysqldriver: deadlock")
+	ErrLockWaitTimeout = errors.New("mysqldriver: lock wait timeout")
+	ErrConnectionLost  = errors.New("mysqldriver: connection lost")
+	ErrReadOnly        = errors.New("mysqldriver: server is read-only")
+	ErrUnknownTable    = errors.New("mysqldriver: unknown table")
+	ErrSyntax          = errors.New("mysqldriver: syntax error")
+)
+
+// Error codes of the server
+// (see https://dev.mysql.com/doc/mysql-errors/en/server-error-reference.html)
+const (
+	erDupKey                           uint16 = 1022
+	erBadTable                         uint16 = 1051
+	erServerShutdown                   uint16 = 1053
+	erDupEntry                         uint16 = 1062
+	erParseError                       uint16 = 1064
+	erUnknownTable                     uint16 = 1109
+	erNoSuchTable                      uint16 = 1146
+	erSyntaxError                      uint16 = 1149
+	erNetReadError                     uint16 = 1158
+	erNetReadInterrupted               uint16 = 1159
+	erNetErrorOnWrite                  uint16 = 1160
+	erNetWriteInterrupted              uint16 = 1161
+	erLockWaitTimeout                  uint16 = 1205
+	erLockDeadlock                     uint16 = 1213
+	erOptionPreventsStatement          uint16 = 1290
+	erDupEntryWithKeyName              uint16 = 1586
+	erCantExecuteInReadOnlyTransaction uint16 = 1792
+	erConnectionKilled                 uint16 = 1927
+)
+
+var errorClasses = map[uint16]error{
+	erDupKey:                           ErrDuplicateKey,
+	erDupEntry:                         ErrDuplicateKey,
+	erDupEntryWithKeyName:              ErrDuplicateKey,
+	erLockDeadlock:                     ErrDeadlock,
+	erLockWaitTimeout:                  ErrLockWaitTimeout,
+	erServerShutdown:                   ErrConnectionLost,
+	erNetReadError:                     ErrConnectionLost,
+	erNetReadInterrupted:               ErrConnectionLost,
+	erNetErrorOnWrite:                  ErrConnectionLost,
+	erNetWriteInterrupted:              ErrConnectionLost,
+	erConnectionKilled:                 ErrConnectionLost,
+	erOptionPreventsStatement:          ErrReadOnly,
+	erCantExecuteInReadOnlyTransaction: ErrReadOnly,
+	erBadTable:                         ErrUnknownTable,
+	erUnknownTable:                     ErrUnknownTable,
+	erNoSuchTable:                      ErrUnknownTable,
+	erParseError:                       ErrSyntax,
+	erSyntaxError:                      ErrSyntax,
+}
+
+// Error is returned when the query fails on the server
+// or because the connection is broken. It wraps either
+// mysqlproto.ERRPacket or I/O error together with the query.
+//  var errPacket mysqlproto.ERRPacket
+//  if errors.As(err, &errPacket) {
+//  	return errPacket.ErrorCode
+//  }
+type Error struct {
+	SQL string // query which failed
+	Err error  // mysqlproto.ERRPacket or I/O error of the connection
+
+	lost bool // connection was broken by Err
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error belongs to the class
+// of errors such as ErrDuplicateKey
+func (e *Error) Is(target error) bool {
+	if pkt, ok := e.Err.(mysqlproto.ERRPacket); ok {
+		return errorClasses[pkt.ErrorCode] == target
+	}
+	return target == ErrConnectionLost && (e.lost || isIOError(e.Err))
+}
+
+// queryError wraps ERR_PACKET of the query or I/O error
+// which broke the connection. Errors of the context and
+// errors which occurred before the query was sent are returned as is.
+func (c *Conn) queryError(sql string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(mysqlproto.ERRPacket); ok {
+		return &Error{SQL: sql, Err: err}
+	}
+	if !c.valid && err != context.Canceled && err != context.DeadlineExceeded && err != ErrClosedStmt {
+		return &Error{SQL: sql, Err: err, lost: true}
+	}
+	return err
+}
+
+// IsDuplicateKey reports whether the query violated
+// unique or primary key
+func IsDuplicateKey(err error) bool {
+	return isClass(err, ErrDuplicateKey)
+}
+
+// IsConnectionError reports whether the connection is broken
+// or can't be established. Such connection is discarded by the pool.
+func IsConnectionError(err error) bool {
+	var connErr *ConnectError
+	if isClass(err, ErrConnectionLost) || errors.As(err, &connErr) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false // query was interrupted, but connection may be alive
+	}
+	return isIOError(err)
+}
+
+// isIOError reports whether the error is returned by the socket
+func isIOError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// IsRetryable reports whether the failure is transient, so the same
+// operation may succeed when it's retried: deadlock, lock wait timeout
+// or connection error. Note that the query may be already applied
+// when the connection is lost, so only idempotent queries are safe
+// to retry in this case.
+func IsRetryable(err error) bool {
+	return isClass(err, ErrDeadlock) || isClass(err, ErrLockWaitTimeout) || IsConnectionError(err)
+}
+
+// isClass matches both wrapped and bare ERR_PACKET
+// with the class of errors
+func isClass(err error, class error) bool {
+	if errors.Is(err, class) {
+		return true
+	}
+	var pkt mysqlproto.ERRPacket
+	return errors.As(err, &pkt) && errorClasses[pkt.ErrorCode] == class
+}
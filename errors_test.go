@@ -0,0 +1,108 @@
+package mysqldriver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorClasses(t *testing.T) {
+	cases := map[uint16]error{
+		1062: ErrDuplicateKey,
+		1586: ErrDuplicateKey,
+		1213: ErrDeadlock,
+		1205: ErrLockWaitTimeout,
+		1927: ErrConnectionLost,
+		1290: ErrReadOnly,
+		1146: ErrUnknownTable,
+		1064: ErrSyntax,
+	}
+	for code, class := range cases {
+		err := error(&Error{SQL: "DO 1", Err: mysqlproto.ERRPacket{ErrorCode: code}})
+		assert.True(t, errors.Is(err, class))
+		assert.False(t, errors.Is(err, ErrClosedDB))
+	}
+
+	err := error(&Error{SQL: "DO 1", Err: mysqlproto.ERRPacket{ErrorCode: 1048}})
+	assert.False(t, errors.Is(err, ErrDuplicateKey))
+	assert.False(t, IsRetryable(err))
+}
+
+func TestErrorWrapsPacket(t *testing.T) {
+	pkt := mysqlproto.ERRPacket{ErrorCode: 1062, ErrorMessage: "Duplicate entry '1' for key 'PRIMARY'"}
+	err := error(&Error{SQL: "INSERT INTO dogs VALUES (1)", Err: pkt})
+	assert.EqualError(t, err, pkt.Error())
+
+	var unwrapped mysqlproto.ERRPacket
+	assert.True(t, errors.As(err, &unwrapped))
+	assert.Equal(t, unwrapped, pkt)
+	assert.True(t, IsDuplicateKey(err))
+	assert.True(t, IsDuplicateKey(pkt))
+	assert.False(t, IsRetryable(err))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&Error{Err: mysqlproto.ERRPacket{ErrorCode: 1213}}))
+	assert.True(t, IsRetryable(mysqlproto.ERRPacket{ErrorCode: 1205}))
+	assert.True(t, IsRetryable(&Error{Err: io.EOF}))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestIsConnectionError(t *testing.T) {
+	assert.True(t, IsConnectionError(&Error{Err: io.ErrUnexpectedEOF}))
+	assert.True(t, errors.Is(&Error{Err: io.ErrUnexpectedEOF}, ErrConnectionLost))
+	assert.False(t, errors.Is(&Error{Err: errors.New("mysqldriver: unexpected packet")}, ErrConnectionLost))
+	assert.True(t, IsConnectionError(io.EOF))
+	assert.True(t, IsConnectionError(&net.OpError{Op: "read", Err: errors.New("connection reset")}))
+	assert.True(t, IsConnectionError(&ConnectError{Step: ConnectStepDial, Err: context.DeadlineExceeded}))
+	assert.True(t, IsConnectionError(mysqlproto.ERRPacket{ErrorCode: 1053}))
+	assert.False(t, IsConnectionError(context.DeadlineExceeded))
+	assert.False(t, IsConnectionError(mysqlproto.ERRPacket{ErrorCode: 1062}))
+	assert.False(t, IsConnectionError(ErrClosedStmt))
+}
+
+func TestQueryErrorOfBrokenConnection(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+
+	conn, err := NewConn("root", "", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	server.dropConns()
+
+	_, err = conn.Exec("DO 1")
+	assert.False(t, conn.valid)
+	queryErr, ok := err.(*Error)
+	assert.True(t, ok)
+	assert.Equal(t, queryErr.SQL, "DO 1")
+	assert.True(t, errors.Is(err, ErrConnectionLost))
+	assert.True(t, IsConnectionError(err))
+}
+
+func TestQueryErrorBeforeSending(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+
+	conn, err := NewConn("root", "", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+
+	_, err = conn.ExecArgs("DO ?", 1, 2)
+	_, ok := err.(*Error)
+	assert.False(t, ok)
+	assert.False(t, IsRetryable(err))
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		_, err := conn.Exec("INSERT INTO people(id, firstname) VALUES (1, 'bob'), (1, 'alice')")
+		assert.True(t, IsDuplicateKey(err))
+		assert.True(t, errors.Is(err, ErrDuplicateKey))
+		assert.False(t, IsRetryable(err))
+	})
+}
@@ -0,0 +1,278 @@
+package mysqldriver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+const (
+	protocolVersion     byte = 10
+	authMoreData        byte = 0x01
+	authSwitchRequest   byte = 0xfe
+	handshakeMaxPacket       = maxPacketSize
+	handshakeFillerSize      = 23
+)
+
+var (
+	errMalformedHandshake = errors.New("mysqldriver: malformed handshake packet")
+	errNoTLS              = errors.New("mysqldriver: server doesn't support TLS")
+	errNoProtocol41       = errors.New("mysqldriver: server doesn't support protocol 4.1")
+)
+
+// handshake is the initial handshake packet sent by the server
+// (see https://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::HandshakeV10)
+type handshake struct {
+	serverVersion   string
+	connectionID    uint32
+	capabilityFlags uint32
+	characterSet    byte
+	statusFlags     uint16
+	authPluginData  []byte
+	authPluginName  string
+}
+
+// handshake reads the greeting of the server, upgrades the connection
+// to TLS when tlsConfig is given and authenticates the user
+func (c *Conn) handshake(cfg *Config, collation byte, tlsConfig *tls.Config) error {
+	c.meter.Conn = c.netConn
+	stream := mysqlproto.NewStream(&c.meter, cfg.ReadTimeout)
+	c.conn = mysqlproto.Conn{Stream: stream}
+
+	pkt, err := stream.NextPacket()
+	if err != nil {
+		return err
+	}
+
+	greeting, err := parseHandshake(pkt.Payload)
+	if err != nil {
+		return err
+	}
+
+	if greeting.capabilityFlags&mysqlproto.CLIENT_PROTOCOL_41 == 0 {
+		return errNoProtocol41
+	}
+
+	c.connectionID = greeting.connectionID
+	flags := capabilityFlags
+	if cfg.MultiStatements {
+		flags |= mysqlproto.CLIENT_MULTI_STATEMENTS
+	}
+	flags &= greeting.capabilityFlags
+	seq := pkt.SequenceID + 1
+
+	// password can be sent in clear text only over secure connection
+	_, secure := c.netConn.(*net.UnixConn)
+
+	if tlsConfig != nil {
+		if greeting.capabilityFlags&mysqlproto.CLIENT_SSL == 0 {
+			return errNoTLS
+		}
+		flags |= mysqlproto.CLIENT_SSL
+
+		// SSL request is the beginning of the handshake response
+		buf := appendHandshakeHeader(append(c.buf[:0], 0, 0, 0, 0), flags, collation)
+		if err = c.writePacket(buf, seq); err != nil {
+			return err
+		}
+		seq++
+
+		tlsConn := tls.Client(&c.meter, tlsConfig)
+		if err = tlsConn.Handshake(); err != nil {
+			return err
+		}
+		stream = mysqlproto.NewStream(tlsConn, cfg.ReadTimeout)
+		secure = true
+	}
+	c.conn = mysqlproto.Conn{Stream: stream, CapabilityFlags: flags}
+	security := authSecurity{secure: secure, allowPublicKey: cfg.AllowPublicKeyRetrieval}
+
+	plugin := greeting.authPluginName
+	authData, err := authResponse(plugin, greeting.authPluginData, cfg.Password, security)
+	if err != nil {
+		// server will ask to switch to the plugin it supports
+		plugin = nativePasswordAuth
+		authData, _ = authResponse(plugin, greeting.authPluginData, cfg.Password, security)
+	}
+
+	buf := appendHandshakeHeader(append(c.buf[:0], 0, 0, 0, 0), flags, collation)
+	buf = append(append(buf, cfg.User...), 0)
+	if flags&mysqlproto.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA != 0 {
+		buf = appendLenEncBytes(buf, authData)
+	} else {
+		buf = append(append(buf, byte(len(authData))), authData...)
+	}
+	if flags&mysqlproto.CLIENT_CONNECT_WITH_DB != 0 {
+		buf = append(append(buf, cfg.DBName...), 0)
+	}
+	if flags&mysqlproto.CLIENT_PLUGIN_AUTH != 0 {
+		buf = append(append(buf, plugin...), 0)
+	}
+	if err = c.writePacket(buf, seq); err != nil {
+		return err
+	}
+
+	return c.readAuthResult(plugin, greeting.authPluginData, cfg.Password, security)
+}
+
+// readAuthResult reads packets sent by the server until
+// the user is either authenticated or rejected
+func (c *Conn) readAuthResult(plugin string, nonce []byte, password string, security authSecurity) error {
+	for {
+		pkt, err := c.conn.NextPacket()
+		if err != nil {
+			return err
+		}
+
+		if len(pkt.Payload) == 0 {
+			return errMalformedHandshake
+		}
+
+		var authData []byte
+		switch pkt.Payload[0] {
+		case mysqlproto.OK_PACKET:
+			okPkt, err := mysqlproto.ParseOKPacket(pkt.Payload, c.conn.CapabilityFlags)
+			if err != nil {
+				return err
+			}
+			c.status = okPkt.StatusFlags
+			return nil
+		case mysqlproto.ERR_PACKET:
+			errPkt, err := mysqlproto.ParseERRPacket(pkt.Payload, c.conn.CapabilityFlags)
+			if err != nil {
+				return err
+			}
+			return errPkt
+		case authSwitchRequest:
+			// (see https://dev.mysql.com/doc/internals/en/connection-phase-packets.html#packet-Protocol::AuthSwitchRequest)
+			payload := pkt.Payload[1:]
+			end := bytes.IndexByte(payload, 0)
+			if end < 0 {
+				return errMalformedHandshake
+			}
+			plugin = string(payload[:end])
+			// copied because the packet's buffer is reused by the following packets
+			nonce = append([]byte(nil), payload[end+1:]...)
+			if len(nonce) > 0 && nonce[len(nonce)-1] == 0 {
+				nonce = nonce[:len(nonce)-1]
+			}
+			if authData, err = authResponse(plugin, nonce, password, security); err != nil {
+				return err
+			}
+		case authMoreData:
+			var reply bool
+			authData, reply, err = authMoreResponse(plugin, pkt.Payload[1:], nonce, password, security)
+			if err != nil {
+				return err
+			}
+			if !reply {
+				continue // OK_PACKET follows
+			}
+		default:
+			return fmt.Errorf("mysqldriver: unexpected packet 0x%02x during authentication", pkt.Payload[0])
+		}
+
+		if err = c.writePacket(append(append(c.buf[:0], 0, 0, 0, 0), authData...), pkt.SequenceID+1); err != nil {
+			return err
+		}
+	}
+}
+
+// writePacket writes the header of the packet which starts
+// with 4 reserved bytes and sends it to the server
+func (c *Conn) writePacket(buf []byte, sequenceID byte) error {
+	c.buf = buf
+	buf, err := finishPacket(buf, sequenceID)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(buf)
+	return err
+}
+
+// appendHandshakeHeader appends the fields shared by
+// SSL request and handshake response packets
+func appendHandshakeHeader(buf []byte, flags uint32, collation byte) []byte {
+	buf = appendUint32(buf, flags)
+	buf = appendUint32(buf, handshakeMaxPacket)
+	buf = append(buf, collation)
+	for i := 0; i < handshakeFillerSize; i++ {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+func parseHandshake(payload []byte) (handshake, error) {
+	var h handshake
+	if len(payload) == 0 {
+		return h, errMalformedHandshake
+	}
+
+	if payload[0] == mysqlproto.ERR_PACKET {
+		// server rejected the connection, e.g. too many connections
+		errPkt, err := mysqlproto.ParseERRPacket(payload, 0)
+		if err != nil {
+			return h, err
+		}
+		return h, errPkt
+	}
+
+	if payload[0] != protocolVersion {
+		return h, fmt.Errorf("mysqldriver: unsupported protocol version %d", payload[0])
+	}
+
+	pos := 1
+	end := bytes.IndexByte(payload[pos:], 0)
+	if end < 0 {
+		return h, errMalformedHandshake
+	}
+	h.serverVersion = string(payload[pos : pos+end])
+	pos += end + 1
+
+	// connection id, first part of auth plugin data, filler and lower capability flags
+	if len(payload) < pos+15 {
+		return h, errMalformedHandshake
+	}
+	h.connectionID = readUint32(payload[pos:])
+	pos += 4
+	h.authPluginData = append(h.authPluginData, payload[pos:pos+8]...)
+	pos += 9
+	h.capabilityFlags = uint32(readUint16(payload[pos:]))
+	pos += 2
+
+	if len(payload) < pos+16 {
+		return h, nil // old servers stop here
+	}
+	h.characterSet = payload[pos]
+	h.statusFlags = readUint16(payload[pos+1:])
+	h.capabilityFlags |= uint32(readUint16(payload[pos+3:])) << 16
+	authDataLen := int(payload[pos+5])
+	pos += 16 // including reserved bytes
+
+	if h.capabilityFlags&mysqlproto.CLIENT_SECURE_CONNECTION != 0 {
+		n := authDataLen - 8
+		if n < 13 {
+			n = 13
+		}
+		if len(payload) < pos+n {
+			return h, errMalformedHandshake
+		}
+		// the last byte is a terminating zero
+		h.authPluginData = append(h.authPluginData, payload[pos:pos+n-1]...)
+		pos += n
+	}
+
+	if h.capabilityFlags&mysqlproto.CLIENT_PLUGIN_AUTH != 0 {
+		name := payload[pos:]
+		if end := bytes.IndexByte(name, 0); end >= 0 {
+			name = name[:end]
+		}
+		h.authPluginName = string(name)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,402 @@
+package mysqldriver
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// greetingPacket builds payload of the initial handshake packet
+func greetingPacket(flags uint32, connectionID uint32, nonce []byte, plugin string) []byte {
+	buf := []byte{protocolVersion}
+	buf = append(buf, "5.7.0-fake"...)
+	buf = append(buf, 0)
+	buf = appendUint32(buf, connectionID)
+	buf = append(buf, nonce[:8]...)
+	buf = append(buf, 0)
+	buf = appendUint16(buf, uint16(flags))
+	buf = append(buf, 33)
+	buf = appendUint16(buf, 0x0002)
+	buf = appendUint16(buf, uint16(flags>>16))
+	buf = append(buf, byte(len(nonce)+1))
+	buf = append(buf, make([]byte, 10)...)
+	buf = append(buf, nonce[8:]...)
+	buf = append(buf, 0)
+	buf = append(buf, plugin...)
+	return append(buf, 0)
+}
+
+// stalledQuery isn't answered by fakeServer until KILL QUERY
+// is received over another connection
+const stalledQuery = "DO SLEEP(60)"
+
+// errInterrupted is ERR_PACKET sent in response to the killed query
+var errInterrupted = []byte{mysqlproto.ERR_PACKET, 0x25, 0x05, '#', '7', '0', '1', '0', '0', 'i', 'n', 't', 'e', 'r', 'r', 'u', 'p', 't', 'e', 'd'}
+
+// fakeServer is a stand-in for MySQL server. It authenticates
+// clients and responds with OK_PACKET to every command.
+type fakeServer struct {
+	tlsConfig *tls.Config     // nil disables TLS
+	password  string          // password of the user
+	plugin    string          // authentication plugin announced in the greeting
+	switchTo  string          // plugin requested with auth switch
+	fullAuth  bool            // caching_sha2_password cache misses
+	key       *rsa.PrivateKey // key used to send password over plain connection
+
+	results map[string][][]byte // payloads sent in response to COM_QUERY instead of OK_PACKET
+	replies [][][]byte          // payloads sent in response to the following prepared statement commands
+	killed  chan struct{}       // KILL QUERY received while stalledQuery is executed
+
+	listener  net.Listener
+	err       chan error // result of the handshake on the server side
+	collation byte       // collation sent by the first client in the handshake
+	once      sync.Once
+
+	mu       sync.Mutex
+	conns    []net.Conn // accepted connections
+	commands []byte     // command bytes received after the handshake
+	infile   []byte     // content of local files received from the client
+}
+
+func startFakeServer(t *testing.T, s *fakeServer) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s.listener = listener
+	s.err = make(chan error, 1)
+	s.killed = make(chan struct{}, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) close() {
+	s.listener.Close()
+}
+
+// dropConns closes all accepted connections
+// as the server does after wait_timeout
+func (s *fakeServer) dropConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeServer) receivedCommands() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte(nil), s.commands...)
+}
+
+// serve handles the connection. Result of the first handshake
+// is sent to s.err channel.
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	flags := mysqlproto.CLIENT_PROTOCOL_41 | mysqlproto.CLIENT_SECURE_CONNECTION |
+		mysqlproto.CLIENT_PLUGIN_AUTH | mysqlproto.CLIENT_CONNECT_WITH_DB |
+		mysqlproto.CLIENT_MULTI_STATEMENTS | mysqlproto.CLIENT_MULTI_RESULTS |
+		mysqlproto.CLIENT_LOCAL_FILES
+	if s.tlsConfig != nil {
+		flags |= mysqlproto.CLIENT_SSL
+	}
+	plugin := s.plugin
+	if plugin == "" {
+		plugin = nativePasswordAuth
+	}
+	nonce := []byte("abcdefghijklmnopqrst")
+	if err := writeFakePacket(conn, 0, greetingPacket(flags, 42, nonce, plugin)); err != nil {
+		s.result(err)
+		return
+	}
+
+	var rw io.ReadWriter = conn
+	seq, payload, err := readFakePacket(rw)
+	if err != nil {
+		s.result(err)
+		return
+	}
+
+	if s.tlsConfig != nil {
+		if readUint32(payload)&mysqlproto.CLIENT_SSL == 0 {
+			s.result(io.ErrUnexpectedEOF)
+			return
+		}
+		tlsConn := tls.Server(conn, s.tlsConfig)
+		if err = tlsConn.Handshake(); err != nil {
+			s.result(err)
+			return
+		}
+		rw = tlsConn
+		if seq, payload, err = readFakePacket(rw); err != nil {
+			s.result(err)
+			return
+		}
+	}
+
+	s.once.Do(func() { s.collation = payload[8] })
+
+	// skip header of the handshake response and username
+	pos := 32
+	for payload[pos] != 0 {
+		pos++
+	}
+	pos++
+	authData := payload[pos+1 : pos+1+int(payload[pos])]
+
+	if s.switchTo != "" {
+		plugin = s.switchTo
+		req := append([]byte{authSwitchRequest}, plugin...)
+		req = append(append(append(req, 0), nonce...), 0)
+		if err = writeFakePacket(rw, seq+1, req); err != nil {
+			s.result(err)
+			return
+		}
+		if seq, authData, err = readFakePacket(rw); err != nil {
+			s.result(err)
+			return
+		}
+	}
+
+	ok, seq, err := s.authenticate(rw, plugin, nonce, seq, authData)
+	if err != nil {
+		s.result(err)
+		return
+	}
+	if !ok {
+		writeFakePacket(rw, seq+1, []byte{mysqlproto.ERR_PACKET, 0x15, 0x04, '#', '2', '8', '0', '0', '0', 'd', 'e', 'n', 'i', 'e', 'd'})
+		s.result(io.ErrUnexpectedEOF)
+		return
+	}
+
+	okPacket := []byte{mysqlproto.OK_PACKET, 0, 0, 0x02, 0, 0, 0}
+	if err = writeFakePacket(rw, seq+1, okPacket); err != nil {
+		s.result(err)
+		return
+	}
+	s.result(nil)
+
+	for {
+		if _, payload, err = readFakePacket(rw); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, payload[0])
+		var packets [][]byte
+		if payload[0] == comStmtPrepare || payload[0] == comStmtExecute {
+			if len(s.replies) > 0 {
+				packets, s.replies = s.replies[0], s.replies[1:]
+			}
+		}
+		s.mu.Unlock()
+		if payload[0] == comStmtClose {
+			continue // server doesn't respond to COM_STMT_CLOSE
+		}
+		if payload[0] == comQuery && string(payload[1:]) == stalledQuery {
+			<-s.killed
+			if err = writeFakePacket(rw, 1, errInterrupted); err != nil {
+				return
+			}
+			continue
+		}
+		if payload[0] == comQuery && strings.HasPrefix(string(payload[1:]), "KILL QUERY ") {
+			select {
+			case s.killed <- struct{}{}:
+			default:
+			}
+		}
+		if payload[0] == comQuery {
+			packets = s.results[string(payload[1:])]
+		}
+		if packets != nil {
+			for i, packet := range packets {
+				if err = writeFakePacket(rw, byte(i+1), packet); err != nil {
+					return
+				}
+			}
+			if packets[0][0] == localInfileRequest && s.receiveLocalFile(rw) != nil {
+				return
+			}
+			continue
+		}
+		if err = writeFakePacket(rw, 1, okPacket); err != nil {
+			return
+		}
+	}
+}
+
+// receiveLocalFile reads the content of the local file requested
+// from the client and responds with OK_PACKET holding the number of lines
+func (s *fakeServer) receiveLocalFile(rw io.ReadWriter) error {
+	var lines uint64
+	for {
+		seq, payload, err := readFakePacket(rw)
+		if err != nil {
+			return err
+		}
+		if len(payload) == 0 {
+			return writeFakePacket(rw, seq+1, okPacket(lines, 0, 0))
+		}
+		s.mu.Lock()
+		s.infile = append(s.infile, payload...)
+		s.mu.Unlock()
+		lines += uint64(bytes.Count(payload, []byte("\n")))
+	}
+}
+
+// authenticate checks authentication data sent by the client.
+// It returns sequence id of the last packet read from the client.
+func (s *fakeServer) authenticate(rw io.ReadWriter, plugin string, nonce []byte, seq byte, authData []byte) (bool, byte, error) {
+	var err error
+	switch plugin {
+	case cachingSHA2PasswordAuth:
+		if !s.fullAuth {
+			if !bytes.Equal(authData, scrambleCachingSHA2Password(nonce, s.password)) {
+				return false, seq, nil
+			}
+			seq++
+			return true, seq, writeFakePacket(rw, seq, []byte{authMoreData, cachingSHA2FastAuthSuccess})
+		}
+		if err = writeFakePacket(rw, seq+1, []byte{authMoreData, cachingSHA2PerformFullAuth}); err != nil {
+			return false, seq, err
+		}
+		if seq, authData, err = readFakePacket(rw); err != nil {
+			return false, seq, err
+		}
+		if s.tlsConfig != nil {
+			return string(authData) == s.password+"\x00", seq, nil
+		}
+		if !bytes.Equal(authData, []byte{cachingSHA2RequestPublicKey}) {
+			return false, seq, nil
+		}
+		return s.authenticateRSA(rw, nonce, seq)
+	case sha256PasswordAuth:
+		if s.tlsConfig != nil {
+			return string(authData) == s.password+"\x00", seq, nil
+		}
+		if !bytes.Equal(authData, []byte{sha256RequestPublicKey}) {
+			return false, seq, nil
+		}
+		return s.authenticateRSA(rw, nonce, seq)
+	}
+	return bytes.Equal(authData, scrambleNativePassword(nonce, s.password)), seq, nil
+}
+
+// authenticateRSA sends public key to the client
+// and decrypts the password sent back
+func (s *fakeServer) authenticateRSA(rw io.ReadWriter, nonce []byte, seq byte) (bool, byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(&s.key.PublicKey)
+	if err != nil {
+		return false, seq, err
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err = writeFakePacket(rw, seq+1, append([]byte{authMoreData}, pemKey...)); err != nil {
+		return false, seq, err
+	}
+
+	seq, encrypted, err := readFakePacket(rw)
+	if err != nil {
+		return false, seq, err
+	}
+	plain, err := rsa.DecryptOAEP(sha1.New(), nil, s.key, encrypted, nil)
+	if err != nil {
+		return false, seq, nil
+	}
+	for i := range plain {
+		plain[i] ^= nonce[i%len(nonce)]
+	}
+	return string(plain) == s.password+"\x00", seq, nil
+}
+
+// result reports result of the first handshake
+func (s *fakeServer) result(err error) {
+	select {
+	case s.err <- err:
+	default:
+	}
+}
+
+func readFakePacket(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, err := io.ReadFull(r, payload)
+	return header[3], payload, err
+}
+
+func writeFakePacket(w io.Writer, seq byte, payload []byte) error {
+	buf, _ := finishPacket(append([]byte{0, 0, 0, 0}, payload...), seq)
+	_, err := w.Write(buf)
+	return err
+}
+
+func TestParseHandshake(t *testing.T) {
+	flags := mysqlproto.CLIENT_PROTOCOL_41 | mysqlproto.CLIENT_SECURE_CONNECTION |
+		mysqlproto.CLIENT_PLUGIN_AUTH | mysqlproto.CLIENT_SSL
+	nonce := []byte("abcdefghijklmnopqrst")
+
+	h, err := parseHandshake(greetingPacket(flags, 42, nonce, nativePasswordAuth))
+	assert.NoError(t, err)
+	assert.Equal(t, h.serverVersion, "5.7.0-fake")
+	assert.Equal(t, h.connectionID, uint32(42))
+	assert.Equal(t, h.capabilityFlags, flags)
+	assert.Equal(t, h.characterSet, byte(33))
+	assert.Equal(t, h.statusFlags, uint16(0x0002))
+	assert.Equal(t, h.authPluginData, nonce)
+	assert.Equal(t, h.authPluginName, nativePasswordAuth)
+}
+
+func TestParseHandshakeErrors(t *testing.T) {
+	_, err := parseHandshake(nil)
+	assert.Equal(t, err, errMalformedHandshake)
+
+	_, err = parseHandshake([]byte{9, 0})
+	assert.EqualError(t, err, "mysqldriver: unsupported protocol version 9")
+
+	_, err = parseHandshake([]byte{protocolVersion, '5', '.', '7'})
+	assert.Equal(t, err, errMalformedHandshake)
+
+	_, err = parseHandshake([]byte{protocolVersion, '5', 0, 1, 2, 3})
+	assert.Equal(t, err, errMalformedHandshake)
+}
+
+func TestNewConnWrongPassword(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{password: "secret"})
+	defer server.close()
+
+	conn, err := NewConn("root", "wrong", "tcp", server.addr(), "test", time.Duration(0))
+	errPkt, ok := err.(mysqlproto.ERRPacket)
+	assert.True(t, ok)
+	assert.Equal(t, errPkt.ErrorCode, uint16(1045))
+	assert.False(t, conn.valid)
+}
@@ -0,0 +1,131 @@
+package mysqldriver
+
+import (
+	"context"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// QueryHook observes queries of the connections established by DB
+// (see DB.QueryHook). It's called synchronously by the goroutine
+// performing the query, so it shouldn't block.
+//
+// Hook is invoked by Query, Exec, QueryArgs, ExecArgs and their
+// context variants of Conn and by Query and Exec of Stmt.
+// For queries returning rows OnQueryEnd is called once all rows
+// are read or reading fails.
+//  type tracer struct{}
+//
+//  func (tracer) OnQueryStart(ctx context.Context, query *mysqldriver.QueryInfo) context.Context {
+//  	ctx, _ = otel.Tracer("mysql").Start(ctx, "query")
+//  	return ctx
+//  }
+//
+//  func (tracer) OnQueryEnd(ctx context.Context, query *mysqldriver.QueryInfo) {
+//  	span := trace.SpanFromContext(ctx)
+//  	span.SetAttributes(attribute.String("db.statement", query.SQL))
+//  	span.End()
+//  }
+type QueryHook interface {
+	// OnQueryStart is called before the query is sent to the server.
+	// Returned context is passed to OnQueryEnd.
+	OnQueryStart(ctx context.Context, query *QueryInfo) context.Context
+
+	// OnQueryEnd is called when the query is completed
+	// with fields of the query result filled in
+	OnQueryEnd(ctx context.Context, query *QueryInfo)
+}
+
+// QueryInfo describes the query observed by QueryHook
+type QueryInfo struct {
+	SQL          string        // text of the query or of the prepared statement
+	Args         []interface{} // arguments of the query
+	ConnectionID uint32        // thread ID of the connection on the server
+	Start        time.Time     // when the query was started
+
+	// Result of the query, set before OnQueryEnd is called
+	Duration     time.Duration // time elapsed since the start including reading of all rows
+	RowsAffected uint64        // number of rows affected by Exec
+	Rows         int           // number of rows read from the result set
+	Stats        Stats         // statistics of the connection collected during the query
+	Err          error         // error of the query or of reading the rows
+}
+
+// queryTrace holds the state of the query observed
+// by the hook or the slow query log
+type queryTrace struct {
+	db    *DB
+	hook  QueryHook
+	ctx   context.Context
+	info  QueryInfo
+	stats Stats // stats of the connection before the query
+}
+
+// startQuery calls the hook of the pool if there is one.
+// Returned trace is nil when neither the hook nor the slow query
+// log is set, so queries aren't slowed down.
+func (c *Conn) startQuery(ctx context.Context, sql string, args []interface{}) *queryTrace {
+	db := c.db
+	if db == nil || db.QueryHook == nil && db.SlowQueryLogger == nil {
+		return nil
+	}
+
+	t := &queryTrace{
+		db:   db,
+		hook: db.QueryHook,
+		info: QueryInfo{
+			SQL:          sql,
+			Args:         args,
+			ConnectionID: c.connectionID,
+			Start:        time.Now(),
+		},
+		stats: c.Stats(),
+	}
+	if t.hook != nil {
+		t.ctx = t.hook.OnQueryStart(ctx, &t.info)
+	}
+	return t
+}
+
+// end completes the trace of the query
+func (t *queryTrace) end(c *Conn, err error) {
+	if t == nil {
+		return
+	}
+
+	t.info.Duration = time.Since(t.info.Start)
+	stats := c.Stats()
+	t.info.Stats = Stats{
+		Syscalls:     stats.Syscalls - t.stats.Syscalls,
+		BytesRead:    stats.BytesRead - t.stats.BytesRead,
+		BytesWritten: stats.BytesWritten - t.stats.BytesWritten,
+	}
+	t.info.Err = err
+	if t.hook != nil {
+		t.hook.OnQueryEnd(t.ctx, &t.info)
+	}
+	if t.db.SlowQueryLogger != nil && t.info.Duration >= t.db.SlowQueryThreshold {
+		t.db.logSlowQuery(&t.info)
+	}
+}
+
+// endExec completes the trace of the query returning OK_PACKET
+func (t *queryTrace) endExec(c *Conn, pkt mysqlproto.OKPacket, err error) {
+	if t != nil {
+		t.info.RowsAffected = pkt.AffectedRows
+		t.end(c, err)
+	}
+}
+
+// attach binds the trace to the rows, so it's completed
+// once all rows are read. If the query failed, the trace
+// is completed immediately.
+func (t *queryTrace) attach(c *Conn, rows *Rows, err error) (*Rows, error) {
+	if err != nil {
+		t.end(c, err)
+		return nil, err
+	}
+	rows.trace = t
+	return rows, nil
+}
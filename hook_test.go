@@ -0,0 +1,159 @@
+package mysqldriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookKey struct{}
+
+type recordingHook struct {
+	started []QueryInfo
+	ended   []QueryInfo
+	values  []interface{} // values of hookKey in contexts passed to OnQueryEnd
+}
+
+func (h *recordingHook) OnQueryStart(ctx context.Context, query *QueryInfo) context.Context {
+	h.started = append(h.started, *query)
+	return context.WithValue(ctx, hookKey{}, "started")
+}
+
+func (h *recordingHook) OnQueryEnd(ctx context.Context, query *QueryInfo) {
+	h.ended = append(h.ended, *query)
+	h.values = append(h.values, ctx.Value(hookKey{}))
+}
+
+func hookedConn(t *testing.T, dataSource string) (*DB, *Conn, *recordingHook) {
+	hook := &recordingHook{}
+	db := NewDB(dataSource, 1, time.Duration(0))
+	db.QueryHook = hook
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	return db, conn, hook
+}
+
+func TestQueryHookExec(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db, conn, hook := hookedConn(t, "root@tcp("+server.addr()+")/test")
+	defer db.Close()
+
+	_, err := conn.ExecArgs("DO ?", 1)
+	assert.NoError(t, err)
+
+	assert.Len(t, hook.started, 1)
+	assert.Len(t, hook.ended, 1)
+	query := hook.ended[0]
+	assert.Equal(t, query.SQL, "DO ?")
+	assert.Equal(t, query.Args, []interface{}{1})
+	assert.Equal(t, query.ConnectionID, uint32(42))
+	assert.Equal(t, query.Stats.BytesWritten, uint64(4+len("\x03DO 1")))
+	assert.Equal(t, query.Stats.BytesRead, uint64(4+7))
+	assert.Nil(t, query.Err)
+	assert.Equal(t, hook.values, []interface{}{"started"})
+}
+
+func TestQueryHookExecError(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db, conn, hook := hookedConn(t, "root@tcp("+server.addr()+")/test")
+	defer db.Close()
+
+	_, err := conn.ExecArgs("DO ?, ?", 1)
+	assert.Error(t, err)
+	assert.Len(t, hook.ended, 1)
+	assert.Equal(t, hook.ended[0].Err, err)
+}
+
+func TestQueryHookContext(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db, conn, hook := hookedConn(t, "root@tcp("+server.addr()+")/test")
+	defer db.Close()
+
+	var captured context.Context
+	db.QueryHook = hookFunc(func(ctx context.Context, query *QueryInfo) context.Context {
+		captured = ctx
+		return hook.OnQueryStart(ctx, query)
+	}, hook.OnQueryEnd)
+
+	ctx := context.WithValue(context.Background(), hookKey{}, "caller")
+	_, err := conn.ExecContext(ctx, "DO 1")
+	assert.NoError(t, err)
+	assert.Equal(t, captured.Value(hookKey{}), "caller")
+	assert.Equal(t, hook.values, []interface{}{"started"})
+}
+
+func TestQueryHookWithoutHookDoesntAllocate(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test", 1, time.Duration(0))
+	defer db.Close()
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		conn.startQuery(context.Background(), "DO 1", nil).end(conn, nil)
+	})
+	assert.Equal(t, allocs, float64(0))
+}
+
+func TestQueryHookRows(t *testing.T) {
+	db, conn, hook := hookedConn(t, "root@tcp(127.0.0.1:3306)/test")
+	defer db.Close()
+
+	rows, err := conn.Query("SELECT 1 UNION SELECT 2")
+	assert.NoError(t, err)
+	assert.Len(t, hook.ended, 0)
+	for rows.Next() {
+		rows.Int()
+	}
+	assert.Len(t, hook.ended, 1)
+	assert.Equal(t, hook.ended[0].SQL, "SELECT 1 UNION SELECT 2")
+	assert.Equal(t, hook.ended[0].Rows, 2)
+	assert.True(t, hook.ended[0].Stats.Syscalls > 0)
+
+	_, err = conn.Query("SELECT unknown")
+	assert.Error(t, err)
+	assert.Len(t, hook.ended, 2)
+	assert.Equal(t, hook.ended[1].Err, err)
+}
+
+func TestQueryHookStmt(t *testing.T) {
+	db, conn, hook := hookedConn(t, "root@tcp(127.0.0.1:3306)/test")
+	defer db.Close()
+
+	stmt, err := conn.Prepare("SELECT ?")
+	assert.NoError(t, err)
+	defer stmt.Close()
+
+	rows, err := stmt.Query(5)
+	assert.NoError(t, err)
+	for rows.Next() {
+		rows.Int()
+	}
+	assert.Len(t, hook.ended, 1)
+	assert.Equal(t, hook.ended[0].SQL, "SELECT ?")
+	assert.Equal(t, hook.ended[0].Args, []interface{}{5})
+	assert.Equal(t, hook.ended[0].Rows, 1)
+}
+
+type funcHook struct {
+	start func(ctx context.Context, query *QueryInfo) context.Context
+	end   func(ctx context.Context, query *QueryInfo)
+}
+
+func hookFunc(start func(context.Context, *QueryInfo) context.Context, end func(context.Context, *QueryInfo)) QueryHook {
+	return funcHook{start: start, end: end}
+}
+
+func (h funcHook) OnQueryStart(ctx context.Context, query *QueryInfo) context.Context {
+	return h.start(ctx, query)
+}
+
+func (h funcHook) OnQueryEnd(ctx context.Context, query *QueryInfo) {
+	h.end(ctx, query)
+}
@@ -0,0 +1,338 @@
+package mysqldriver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// QueryArgs substitutes "?" placeholders of SELECT query with the arguments
+// and executes it the same way as func (*Conn) Query does.
+// Arguments are escaped on the client side, so it can be used when
+// server-side prepared statements aren't available (see func (*Conn) Prepare).
+// Supported types of arguments are nil, integers, floats, bool,
+// string, []byte and time.Time. When charset of the connection may
+// contain backslash byte in multibyte characters (e.g. gbk or sjis),
+// strings are sent as hex literals with the charset introducer.
+// Without arguments the query is sent as is, so "?" isn't a placeholder.
+//  rows, err := conn.QueryArgs("SELECT id FROM dogs WHERE name = ? AND age > ?", "Rex", 3)
+func (c *Conn) QueryArgs(sql string, args ...interface{}) (*Rows, error) {
+	trace := c.startQuery(context.Background(), sql, args)
+	req, err := c.request(sql, args)
+	if err != nil {
+		return trace.attach(c, nil, err)
+	}
+	rows, err := c.query(req)
+	return trace.attach(c, rows, c.queryError(sql, err))
+}
+
+// ExecArgs substitutes "?" placeholders of the query with the arguments
+// and executes it the same way as func (*Conn) Exec does.
+// See func (*Conn) QueryArgs for supported types of arguments.
+//  okPacket, err := conn.ExecArgs("DELETE FROM dogs WHERE id = ?", 1)
+func (c *Conn) ExecArgs(sql string, args ...interface{}) (mysqlproto.OKPacket, error) {
+	trace := c.startQuery(context.Background(), sql, args)
+	req, err := c.request(sql, args)
+	if err != nil {
+		trace.end(c, err)
+		return mysqlproto.OKPacket{}, err
+	}
+	pkt, err := c.exec(req)
+	err = c.queryError(sql, err)
+	trace.endExec(c, pkt, err)
+	return pkt, err
+}
+
+// request builds COM_QUERY packet substituting placeholders
+// only when arguments are given
+func (c *Conn) request(sql string, args []interface{}) ([]byte, error) {
+	if len(args) == 0 {
+		return mysqlproto.ComQueryRequest([]byte(sql)), nil
+	}
+	return c.interpolate(sql, args)
+}
+
+// interpolate builds COM_QUERY packet in the connection's buffer
+func (c *Conn) interpolate(sql string, args []interface{}) ([]byte, error) {
+	buf, err := appendInterpolated(startPacket(c.buf, comQuery), sql, args, c.escapeMode())
+	c.buf = buf
+	if err != nil {
+		return nil, err
+	}
+	return finishPacket(buf, 0)
+}
+
+// appendInterpolated appends the query with placeholders replaced by arguments.
+// Placeholders inside of quoted strings, identifiers and comments are ignored.
+func appendInterpolated(buf []byte, sql string, args []interface{}, mode escapeMode) ([]byte, error) {
+	noBackslashEscapes := mode.noBackslash
+	var arg int
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case ch == '?':
+			if arg == len(args) {
+				return buf, fmt.Errorf("mysqldriver: query has more placeholders than %d arguments", len(args))
+			}
+			var err error
+			if buf, err = appendArg(buf, args[arg], arg, mode); err != nil {
+				return buf, err
+			}
+			arg++
+			continue
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := skipQuoted(sql, i, noBackslashEscapes || ch == '`')
+			buf = append(buf, sql[i:end]...)
+			i = end - 1
+			continue
+		case ch == '#' || (ch == '-' && i+2 < len(sql) && sql[i+1] == '-' && (sql[i+2] == ' ' || sql[i+2] == '\t')):
+			end := i
+			for end < len(sql) && sql[end] != '\n' {
+				end++
+			}
+			buf = append(buf, sql[i:end]...)
+			i = end - 1
+			continue
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := i + 2
+			for end < len(sql) && !(sql[end-1] == '*' && sql[end] == '/' && end > i+2) {
+				end++
+			}
+			if end < len(sql) {
+				end++
+			}
+			buf = append(buf, sql[i:end]...)
+			i = end - 1
+			continue
+		}
+		buf = append(buf, ch)
+	}
+
+	if arg != len(args) {
+		return buf, fmt.Errorf("mysqldriver: query expects %d arguments, got %d", arg, len(args))
+	}
+	return buf, nil
+}
+
+// skipQuoted returns position after the closing quote
+// of the string or identifier which starts at the position i
+func skipQuoted(sql string, i int, noBackslashEscapes bool) int {
+	quote := sql[i]
+	for i++; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if !noBackslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote { // doubled quote
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func appendArg(buf []byte, arg interface{}, pos int, mode escapeMode) ([]byte, error) {
+	switch v := arg.(type) {
+	case nil:
+		return append(buf, "NULL"...), nil
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(buf, v, 10), nil
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), nil
+	case float32:
+		return appendFloat(buf, float64(v), 32, pos)
+	case float64:
+		return appendFloat(buf, v, 64, pos)
+	case bool:
+		if v {
+			return append(buf, '1'), nil
+		}
+		return append(buf, '0'), nil
+	case string:
+		return appendStringLiteral(buf, v, mode), nil
+	case []byte:
+		if v == nil {
+			return append(buf, "NULL"...), nil
+		}
+		return appendBytesLiteral(buf, v, mode), nil
+	case time.Time:
+		return appendTime(buf, v), nil
+	}
+	return buf, fmt.Errorf("mysqldriver: unsupported type %T of argument %d", arg, pos)
+}
+
+func appendFloat(buf []byte, f float64, bitSize, pos int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return buf, fmt.Errorf("mysqldriver: unsupported value %v of argument %d", f, pos)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize), nil
+}
+
+// appendTime appends time in its own location as DATETIME literal.
+// Zero time is represented as '0000-00-00'.
+func appendTime(buf []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(buf, "'0000-00-00'"...)
+	}
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	buf = append(buf, '\'')
+	buf = appendDigits(buf, year, 4)
+	buf = append(buf, '-')
+	buf = appendDigits(buf, int(month), 2)
+	buf = append(buf, '-')
+	buf = appendDigits(buf, day, 2)
+	buf = append(buf, ' ')
+	buf = appendDigits(buf, hour, 2)
+	buf = append(buf, ':')
+	buf = appendDigits(buf, min, 2)
+	buf = append(buf, ':')
+	buf = appendDigits(buf, sec, 2)
+	if micro := t.Nanosecond() / 1000; micro != 0 {
+		buf = appendFraction(buf, micro, 6)
+	}
+	return append(buf, '\'')
+}
+
+// escapeMode defines how string literals are escaped
+type escapeMode struct {
+	noBackslash bool // NO_BACKSLASH_ESCAPES SQL mode is set
+
+	// hexCharset is set when charset of the connection may have 0x5c
+	// as a part of multibyte character, e.g. gbk or sjis. Escaping
+	// byte by byte isn't safe then, so strings are sent as
+	// _charset X'..' literals.
+	hexCharset string
+}
+
+// asciiSafeCharsets are charsets which never use ASCII bytes
+// as a part of multibyte characters
+var asciiSafeCharsets = map[string]bool{
+	"armscii8": true, "ascii": true, "binary": true, "cp1250": true,
+	"cp1251": true, "cp1256": true, "cp1257": true, "cp850": true,
+	"cp852": true, "cp866": true, "dec8": true, "eucjpms": true,
+	"euckr": true, "gb2312": true, "geostd8": true, "greek": true,
+	"hebrew": true, "hp8": true, "keybcs2": true, "koi8r": true,
+	"koi8u": true, "latin1": true, "latin2": true, "latin5": true,
+	"latin7": true, "macce": true, "macroman": true, "swe7": true,
+	"tis620": true, "ujis": true, "utf8": true, "utf8mb3": true,
+	"utf8mb4": true,
+}
+
+// escapeMode returns the mode of escaping string literals
+// according to the charset and SQL mode of the connection
+func (c *Conn) escapeMode() escapeMode {
+	mode := escapeMode{noBackslash: c.status&serverStatusNoBackslashEscapes != 0}
+	if !asciiSafeCharsets[c.charset] {
+		mode.hexCharset = c.charset
+	}
+	return mode
+}
+
+// appendStringLiteral appends the string as quoted literal
+func appendStringLiteral(buf []byte, s string, mode escapeMode) []byte {
+	if mode.hexCharset != "" {
+		return appendHexLiteral(buf, s, mode.hexCharset)
+	}
+	buf = append(buf, '\'')
+	buf = appendEscapedString(buf, s, mode.noBackslash)
+	return append(buf, '\'')
+}
+
+// appendBytesLiteral appends the bytes as binary string literal
+func appendBytesLiteral(buf []byte, b []byte, mode escapeMode) []byte {
+	if mode.hexCharset != "" {
+		return appendHexBytes(buf, b)
+	}
+	buf = append(buf, "_binary'"...)
+	buf = appendEscapedBytes(buf, b, mode.noBackslash)
+	return append(buf, '\'')
+}
+
+// appendHexLiteral appends X'..' literal with the charset introducer,
+// so it's a string in the charset instead of a binary string.
+func appendHexLiteral(buf []byte, s string, charset string) []byte {
+	buf = append(buf, '_')
+	buf = append(buf, charset...)
+	buf = append(buf, " X'"...)
+	for i := 0; i < len(s); i++ {
+		buf = append(buf, hexDigits[s[i]>>4], hexDigits[s[i]&0x0f])
+	}
+	return append(buf, '\'')
+}
+
+// appendHexBytes appends X'..' literal which is a binary string
+func appendHexBytes(buf []byte, b []byte) []byte {
+	buf = append(buf, 'X', '\'')
+	for _, ch := range b {
+		buf = append(buf, hexDigits[ch>>4], hexDigits[ch&0x0f])
+	}
+	return append(buf, '\'')
+}
+
+const hexDigits = "0123456789ABCDEF"
+
+// appendEscapedString escapes special characters of the string literal.
+// Quotes are always doubled which is correct regardless
+// of NO_BACKSLASH_ESCAPES SQL mode.
+func appendEscapedString(buf []byte, s string, noBackslashEscapes bool) []byte {
+	for i := 0; i < len(s); i++ {
+		buf = appendEscapedByte(buf, s[i], noBackslashEscapes)
+	}
+	return buf
+}
+
+// appendEscapedBytes is the same as appendEscapedString
+// but it doesn't require to convert bytes to string
+func appendEscapedBytes(buf []byte, b []byte, noBackslashEscapes bool) []byte {
+	for _, ch := range b {
+		buf = appendEscapedByte(buf, ch, noBackslashEscapes)
+	}
+	return buf
+}
+
+func appendEscapedByte(buf []byte, ch byte, noBackslashEscapes bool) []byte {
+	if ch == '\'' {
+		return append(buf, '\'', '\'')
+	}
+	if noBackslashEscapes {
+		return append(buf, ch)
+	}
+	switch ch {
+	case 0:
+		return append(buf, '\\', '0')
+	case '\n':
+		return append(buf, '\\', 'n')
+	case '\r':
+		return append(buf, '\\', 'r')
+	case '\x1a':
+		return append(buf, '\\', 'Z')
+	case '\\':
+		return append(buf, '\\', '\\')
+	}
+	return append(buf, ch)
+}
@@ -0,0 +1,115 @@
+package mysqldriver
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendInterpolated(t *testing.T) {
+	date := time.Date(2017, time.March, 4, 15, 6, 7, 8000, time.UTC)
+	sql, err := appendInterpolated(nil,
+		"SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?",
+		[]interface{}{nil, -1, uint8(2), 4.5, true, "it's", []byte("a\\b"), date, time.Time{}, []byte(nil)},
+		escapeMode{},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, string(sql), `SELECT NULL, -1, 2, 4.5, 1, 'it''s', _binary'a\\b', '2017-03-04 15:06:07.000008', '0000-00-00', NULL`)
+}
+
+func TestAppendInterpolatedEscaping(t *testing.T) {
+	sql, err := appendInterpolated(nil, "SELECT ?", []interface{}{"a\x00\n\r\x1a\\'\""}, escapeMode{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(sql), `SELECT 'a\0\n\r\Z\\''"'`)
+
+	sql, err = appendInterpolated(nil, "SELECT ?", []interface{}{"a\x00\n\\'\""}, escapeMode{noBackslash: true})
+	assert.NoError(t, err)
+	assert.Equal(t, string(sql), "SELECT 'a\x00\n\\''\"'")
+}
+
+func TestAppendInterpolatedMultibyteCharset(t *testing.T) {
+	// 0xa4 0x5c is a single character in big5 and gbk, so escaped
+	// backslash would leave the quote unescaped
+	sql, err := appendInterpolated(nil, "SELECT ?, ?", []interface{}{"\xa4\x5c' OR 1", []byte("\xa4\x5c'")}, escapeMode{hexCharset: "gbk"})
+	assert.NoError(t, err)
+	// strings keep the charset, so they're compared by the column's collation
+	assert.Equal(t, string(sql), "SELECT _gbk X'A45C27204F522031', X'A45C27'")
+
+	assert.Equal(t, (&Conn{charset: "gbk"}).escapeMode(), escapeMode{hexCharset: "gbk"})
+	assert.Equal(t, (&Conn{charset: "utf8mb4", status: serverStatusNoBackslashEscapes}).escapeMode(), escapeMode{noBackslash: true})
+}
+
+func TestAppendInterpolatedIgnoresQuotedPlaceholders(t *testing.T) {
+	sql, err := appendInterpolated(nil,
+		"SELECT '?', \"\\\"?\", `?`, 'it''s ?' /* ? */, ? -- ?\n# ?\n, ?",
+		[]interface{}{1, 2},
+		escapeMode{},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, string(sql), "SELECT '?', \"\\\"?\", `?`, 'it''s ?' /* ? */, 1 -- ?\n# ?\n, 2")
+}
+
+func TestAppendInterpolatedArgumentsMismatch(t *testing.T) {
+	_, err := appendInterpolated(nil, "SELECT ?, ?", []interface{}{1}, escapeMode{})
+	assert.EqualError(t, err, "mysqldriver: query has more placeholders than 1 arguments")
+
+	_, err = appendInterpolated(nil, "SELECT ?", []interface{}{1, 2}, escapeMode{})
+	assert.EqualError(t, err, "mysqldriver: query expects 1 arguments, got 2")
+}
+
+func TestAppendInterpolatedUnsupportedArguments(t *testing.T) {
+	_, err := appendInterpolated(nil, "SELECT ?, ?", []interface{}{1, struct{}{}}, escapeMode{})
+	assert.EqualError(t, err, "mysqldriver: unsupported type struct {} of argument 1")
+
+	_, err = appendInterpolated(nil, "SELECT ?", []interface{}{math.NaN()}, escapeMode{})
+	assert.EqualError(t, err, "mysqldriver: unsupported value NaN of argument 0")
+}
+
+func TestQueryArgs(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		pkt, err := conn.ExecArgs(`INSERT INTO people(firstname,lastname,age) VALUES(?,?,?)`, "o'neil", "a\\b", 42)
+		assert.NoError(t, err)
+		assert.Equal(t, pkt.AffectedRows, uint64(1))
+
+		rows, err := conn.QueryArgs("SELECT lastname, age FROM people WHERE firstname = ?", "o'neil")
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		assert.Equal(t, rows.String(), "a\\b")
+		assert.Equal(t, rows.Int(), 42)
+		assert.False(t, rows.Next())
+	})
+}
+
+func TestQueryArgsNoBackslashEscapes(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		_, err := conn.Exec(`SET SESSION sql_mode = CONCAT(@@sql_mode, ',NO_BACKSLASH_ESCAPES')`)
+		assert.NoError(t, err)
+		assert.True(t, conn.status&serverStatusNoBackslashEscapes != 0)
+
+		_, err = conn.ExecArgs(`INSERT INTO people(firstname) VALUES(?)`, "a\\'b")
+		assert.NoError(t, err)
+
+		rows, err := conn.QueryArgs("SELECT firstname FROM people WHERE firstname = ?", "a\\'b")
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		assert.Equal(t, rows.String(), "a\\'b")
+		assert.False(t, rows.Next())
+
+		_, err = conn.Exec(`SET SESSION sql_mode = DEFAULT`)
+		assert.NoError(t, err)
+	})
+}
+
+func TestExecArgsWithoutArguments(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	conn, err := NewConn("root", "", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// query is sent as is
+	_, err = conn.ExecArgs("DO ?")
+	assert.NoError(t, err)
+}
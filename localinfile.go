@@ -0,0 +1,189 @@
+package mysqldriver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// localInfileRequest is the header of the packet
+// requesting the content of the local file from the client
+// (see https://dev.mysql.com/doc/internals/en/com-query-response.html#local-infile-request)
+const localInfileRequest = 0xfb
+
+// defaultLocalInfileChunk is the size of the packet with the content
+// of the local file when max_allowed_packet of the server isn't known yet.
+// It's below the default value (4MB in MySQL 5.7).
+const defaultLocalInfileChunk = 1 << 20
+
+var (
+	localFilesMu sync.RWMutex
+	localFiles   = make(map[string]struct{})
+)
+
+// localInfileBufs holds buffers of the local file content between loads.
+// Buffers are sized by max_allowed_packet, so they aren't kept
+// by the connection for the rest of its life.
+var localInfileBufs sync.Pool
+
+// RegisterLocalFile allows the file to be sent to the server
+// by LOAD DATA LOCAL INFILE statement. Server may request any file
+// from the client, so files which aren't registered are refused.
+//  mysqldriver.RegisterLocalFile("/data/dogs.csv")
+//  pkt, err := conn.Exec("LOAD DATA LOCAL INFILE '/data/dogs.csv' INTO TABLE dogs")
+// To load data from io.Reader see func (*Conn) LoadData
+func RegisterLocalFile(path string) {
+	localFilesMu.Lock()
+	localFiles[filepath.Clean(path)] = struct{}{}
+	localFilesMu.Unlock()
+}
+
+// DeregisterLocalFile removes the file registered with RegisterLocalFile
+func DeregisterLocalFile(path string) {
+	localFilesMu.Lock()
+	delete(localFiles, filepath.Clean(path))
+	localFilesMu.Unlock()
+}
+
+func localFileRegistered(path string) bool {
+	localFilesMu.RLock()
+	_, ok := localFiles[filepath.Clean(path)]
+	localFilesMu.RUnlock()
+	return ok
+}
+
+// LoadData executes LOAD DATA LOCAL INFILE statement sending
+// the content of the reader instead of the file named by the statement,
+// so the file name is arbitrary. It returns OK_PACKET holding
+// the number of loaded rows and warnings.
+//  file, _ := os.Open("dogs.csv")
+//  defer file.Close()
+//  pkt, err := conn.LoadData("LOAD DATA LOCAL INFILE 'dogs' INTO TABLE dogs FIELDS TERMINATED BY ','", file)
+//  if err != nil {
+//  	// handle error
+//  }
+//  pkt.AffectedRows // number of loaded dogs
+// When reading fails, rows read so far are still sent to the server,
+// so the load should be done in a transaction to be rolled back.
+func (c *Conn) LoadData(sql string, r io.Reader) (mysqlproto.OKPacket, error) {
+	// packets of the content are sized by max_allowed_packet
+	// which can't be queried once the server requests the content
+	if _, err := c.maxPacket(); err != nil {
+		return mysqlproto.OKPacket{}, err
+	}
+
+	trace := c.startQuery(context.Background(), sql, nil)
+	pkt, err := c.loadData(mysqlproto.ComQueryRequest([]byte(sql)), r)
+	err = c.queryError(sql, err)
+	trace.endExec(c, pkt, err)
+	return pkt, err
+}
+
+func (c *Conn) loadData(req []byte, r io.Reader) (mysqlproto.OKPacket, error) {
+	if _, err := c.conn.Write(req); err != nil {
+		c.valid = false
+		return mysqlproto.OKPacket{}, err
+	}
+
+	packet, err := c.conn.NextPacket()
+	if err != nil {
+		c.valid = false
+		return mysqlproto.OKPacket{}, err
+	}
+	if packet.Payload[0] != localInfileRequest {
+		// statement isn't LOAD DATA LOCAL INFILE
+		return c.parseOK(packet)
+	}
+	return c.sendLocalData(r, packet.SequenceID+1)
+}
+
+// sendLocalFile responds to the request of the local file made by
+// the statement other than func (*Conn) LoadData. Server expects
+// the content even when the file can't be read, so nothing is sent
+// in this case before the empty packet ending the content.
+func (c *Conn) sendLocalFile(name string, seq byte) (mysqlproto.OKPacket, error) {
+	if !localFileRegistered(name) {
+		err := fmt.Errorf("mysqldriver: local file %q isn't registered (see func RegisterLocalFile)", name)
+		return c.refuseLocalData(seq, err)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return c.refuseLocalData(seq, err)
+	}
+	defer file.Close()
+	return c.sendLocalData(file, seq)
+}
+
+func (c *Conn) refuseLocalData(seq byte, err error) (mysqlproto.OKPacket, error) {
+	if _, sendErr := c.sendLocalData(nil, seq); !c.valid {
+		return mysqlproto.OKPacket{}, sendErr
+	}
+	return mysqlproto.OKPacket{}, err
+}
+
+// sendLocalData sends the content of the reader in packets
+// followed by the empty one and reads the result of the statement.
+// Nil reader is sent as empty content.
+func (c *Conn) sendLocalData(r io.Reader, seq byte) (mysqlproto.OKPacket, error) {
+	var errRead error
+	if r != nil {
+		size := 4 + c.localInfileChunk()
+		bufp, _ := localInfileBufs.Get().(*[]byte)
+		if bufp == nil || cap(*bufp) < size {
+			buf := make([]byte, size)
+			bufp = &buf
+		}
+		defer localInfileBufs.Put(bufp)
+		buf := (*bufp)[:size]
+		for {
+			n, err := io.ReadFull(r, buf[4:])
+			if n > 0 {
+				req, _ := finishPacket(buf[:4+n], seq)
+				if _, err := c.conn.Write(req); err != nil {
+					c.valid = false
+					return mysqlproto.OKPacket{}, err
+				}
+				seq++
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				errRead = err
+				break
+			}
+		}
+	}
+
+	req, _ := finishPacket(append(c.buf[:0], 0, 0, 0, 0), seq)
+	if _, err := c.conn.Write(req); err != nil {
+		c.valid = false
+		return mysqlproto.OKPacket{}, err
+	}
+
+	pkt, err := c.readOK()
+	if errRead != nil {
+		return pkt, errRead
+	}
+	return pkt, err
+}
+
+// localInfileChunk returns the size of the packet with the content
+// of the local file. Packets larger than max_allowed_packet are
+// refused by the server. The largest packet isn't used because
+// the server would expect its continuation instead of the empty packet.
+func (c *Conn) localInfileChunk() int {
+	switch {
+	case c.maxAllowedPacket == 0:
+		return defaultLocalInfileChunk
+	case c.maxAllowedPacket >= maxPacketSize:
+		return maxPacketSize - 1
+	}
+	return c.maxAllowedPacket
+}
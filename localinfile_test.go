@@ -0,0 +1,107 @@
+package mysqldriver
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// localInfileMaxPacket is max_allowed_packet of the fake server
+const localInfileMaxPacket = 1000
+
+func localInfileConn(t *testing.T, sql, name string) (*DB, *Conn, *fakeServer) {
+	return bulkConn(t, localInfileMaxPacket, map[string][][]byte{sql: {append([]byte{localInfileRequest}, name...)}})
+}
+
+func TestLoadData(t *testing.T) {
+	sql := "LOAD DATA LOCAL INFILE 'dogs' INTO TABLE dogs"
+	db, conn, server := localInfileConn(t, sql, "dogs")
+	defer server.close()
+	defer db.Close()
+
+	// content doesn't fit into a single packet
+	data := strings.Repeat("Rex,3\n", localInfileMaxPacket)
+	capacity := cap(conn.buf)
+	pkt, err := conn.LoadData(sql, strings.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, pkt.AffectedRows, uint64(localInfileMaxPacket))
+	// buffer sized by max_allowed_packet isn't retained by the connection
+	assert.Equal(t, cap(conn.buf), capacity)
+	server.mu.Lock()
+	assert.Equal(t, string(server.infile), data)
+	server.mu.Unlock()
+
+	// max_allowed_packet is queried once
+	_, err = conn.LoadData(sql, strings.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, server.receivedCommands(), []byte{comQuery, comQuery, comQuery})
+
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+}
+
+func TestLoadDataReadError(t *testing.T) {
+	sql := "LOAD DATA LOCAL INFILE 'dogs' INTO TABLE dogs"
+	db, conn, server := localInfileConn(t, sql, "dogs")
+	defer server.close()
+	defer db.Close()
+
+	errBroken := errors.New("broken reader")
+	r := io.MultiReader(strings.NewReader("Rex,3\n"), &failingReader{err: errBroken})
+	_, err := conn.LoadData(sql, r)
+	assert.True(t, errors.Is(err, errBroken))
+
+	// connection is in sync
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+}
+
+func TestLocalFileNotRegistered(t *testing.T) {
+	sql := "LOAD DATA LOCAL INFILE '/etc/passwd' INTO TABLE dogs"
+	db, conn, server := localInfileConn(t, sql, "/etc/passwd")
+	defer server.close()
+	defer db.Close()
+
+	_, err := conn.Exec(sql)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), `local file "/etc/passwd" isn't registered`))
+	server.mu.Lock()
+	assert.Len(t, server.infile, 0)
+	server.mu.Unlock()
+
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+}
+
+func TestLocalFileRegistered(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "dogs.csv")
+	assert.NoError(t, os.WriteFile(name, []byte("Rex,3\nMax,5\n"), 0600))
+	RegisterLocalFile(name)
+	defer DeregisterLocalFile(name)
+
+	sql := "LOAD DATA LOCAL INFILE '" + name + "' INTO TABLE dogs"
+	db, conn, server := localInfileConn(t, sql, name)
+	defer server.close()
+	defer db.Close()
+
+	pkt, err := conn.Exec(sql)
+	assert.NoError(t, err)
+	assert.Equal(t, pkt.AffectedRows, uint64(2))
+	server.mu.Lock()
+	assert.True(t, bytes.Equal(server.infile, []byte("Rex,3\nMax,5\n")))
+	server.mu.Unlock()
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
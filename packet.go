@@ -0,0 +1,101 @@
+package mysqldriver
+
+import (
+	"errors"
+)
+
+// Command bytes
+// (see https://dev.mysql.com/doc/internals/en/text-protocol.html)
+const (
+	comQuery       byte = 0x03
+	comPing        byte = 0x0e
+	comStmtPrepare byte = 0x16
+	comStmtExecute byte = 0x17
+	comStmtClose   byte = 0x19
+)
+
+// maxPacketSize is the largest payload which fits into a single packet
+const maxPacketSize = 1<<24 - 1
+
+var errPacketTooLarge = errors.New("mysqldriver: packet is too large")
+
+// startPacket resets the buffer and reserves space for the packet header
+// followed by the command byte
+func startPacket(buf []byte, command byte) []byte {
+	return append(buf[:0], 0, 0, 0, 0, command)
+}
+
+// finishPacket writes the header of the packet built by startPacket
+func finishPacket(buf []byte, sequenceID byte) ([]byte, error) {
+	length := len(buf) - 4
+	if length > maxPacketSize {
+		return buf, errPacketTooLarge
+	}
+	buf[0] = byte(length)
+	buf[1] = byte(length >> 8)
+	buf[2] = byte(length >> 16)
+	buf[3] = sequenceID
+	return buf, nil
+}
+
+func appendUint16(buf []byte, n uint16) []byte {
+	return append(buf, byte(n), byte(n>>8))
+}
+
+func appendUint32(buf []byte, n uint32) []byte {
+	return append(buf, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return append(buf,
+		byte(n), byte(n>>8), byte(n>>16), byte(n>>24),
+		byte(n>>32), byte(n>>40), byte(n>>48), byte(n>>56),
+	)
+}
+
+func appendLenEncInt(buf []byte, n uint64) []byte {
+	switch {
+	case n < 251:
+		return append(buf, byte(n))
+	case n < 1<<16:
+		return append(buf, 0xfc, byte(n), byte(n>>8))
+	case n < 1<<24:
+		return append(buf, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		return appendUint64(append(buf, 0xfe), n)
+	}
+}
+
+func appendLenEncString(buf []byte, s string) []byte {
+	return append(appendLenEncInt(buf, uint64(len(s))), s...)
+}
+
+func appendLenEncBytes(buf []byte, b []byte) []byte {
+	return append(appendLenEncInt(buf, uint64(len(b))), b...)
+}
+
+func readUint16(b []byte) uint16 {
+	return uint16(b[0]) | uint16(b[1])<<8
+}
+
+func readUint32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func readUint64(b []byte) uint64 {
+	return uint64(readUint32(b)) | uint64(readUint32(b[4:]))<<32
+}
+
+// readLenEncInt decodes length-encoded integer at the offset
+// and returns it together with the offset of the next value
+func readLenEncInt(b []byte, offset uint64) (uint64, uint64) {
+	switch b[offset] {
+	case 0xfc:
+		return uint64(readUint16(b[offset+1:])), offset + 3
+	case 0xfd:
+		return uint64(b[offset+1]) | uint64(b[offset+2])<<8 | uint64(b[offset+3])<<16, offset + 4
+	case 0xfe:
+		return readUint64(b[offset+1:]), offset + 9
+	}
+	return uint64(b[offset]), offset + 1
+}
@@ -0,0 +1,143 @@
+package mysqldriver
+
+import (
+	"context"
+	"time"
+)
+
+// connRequest is sent to GetConnContext call waiting
+// for a connection of the bounded pool. Request without
+// connection and error allows the waiter to dial a new connection.
+type connRequest struct {
+	conn *Conn
+	err  error
+}
+
+// acquire dials a new connection if the limit of open connections
+// isn't reached or waits until another connection is returned to the pool
+func (db *DB) acquire(ctx context.Context) (*Conn, error) {
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return nil, ErrClosedDB
+	}
+	if db.open < db.config.MaxOpen {
+		db.open++
+		db.mu.Unlock()
+		return db.dialSlot(ctx)
+	}
+	req := make(chan connRequest, 1)
+	db.waiters = append(db.waiters, req)
+	db.mu.Unlock()
+
+	start := time.Now()
+	defer func() { db.stats.addWait(time.Since(start)) }()
+
+	select {
+	case r := <-req:
+		if r.err != nil {
+			return nil, r.err
+		}
+		if r.conn == nil {
+			return db.dialSlot(ctx)
+		}
+		return r.conn, nil
+	case <-ctx.Done():
+		db.mu.Lock()
+		removed := db.removeWaiter(req)
+		db.mu.Unlock()
+		if !removed {
+			// request was sent concurrently
+			r := <-req
+			if r.conn != nil {
+				db.PutConn(r.conn)
+			} else if r.err == nil {
+				db.releaseSlot()
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// dialSlot dials a connection which already occupies
+// the slot of the bounded pool
+func (db *DB) dialSlot(ctx context.Context) (*Conn, error) {
+	conn, err := db.dial(ctx)
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		db.releaseSlot()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handOver passes the connection to the first waiter
+func (db *DB) handOver(conn *Conn) bool {
+	if db.config.MaxOpen <= 0 {
+		return false
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(db.waiters) == 0 {
+		return false
+	}
+	req := db.waiters[0]
+	db.waiters = db.waiters[1:]
+	req <- connRequest{conn: conn}
+	return true
+}
+
+// discard closes the connection and frees its slot in the bounded pool
+func (db *DB) discard(conn *Conn) error {
+	if conn.db != db {
+		return conn.Close()
+	}
+
+	conn.db = nil // connection is discarded only once
+	db.stats.addConn(conn.Stats())
+	err := conn.Close()
+	db.stats.addOpen(-1)
+	if db.config.MaxOpen > 0 {
+		db.releaseSlot()
+	}
+	return err
+}
+
+// releaseSlot allows the first waiter to dial a new connection
+// or decreases number of open connections if there are no waiters
+func (db *DB) releaseSlot() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(db.waiters) > 0 {
+		req := db.waiters[0]
+		db.waiters = db.waiters[1:]
+		req <- connRequest{}
+		return
+	}
+	db.open--
+}
+
+func (db *DB) removeWaiter(req chan connRequest) bool {
+	for i, waiter := range db.waiters {
+		if waiter == req {
+			db.waiters = append(db.waiters[:i], db.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// reserveSlot occupies the slot of the bounded pool
+// if the limit of open connections isn't reached
+func (db *DB) reserveSlot() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed || db.open >= db.config.MaxOpen {
+		return false
+	}
+	db.open++
+	return true
+}
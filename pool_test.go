@@ -0,0 +1,228 @@
+package mysqldriver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeDB creates the pool of connections to the fake server
+// with the config adjusted by the test
+func newFakeDB(t *testing.T, configure func(cfg *Config)) (*DB, *fakeServer) {
+	server := startFakeServer(t, &fakeServer{})
+	cfg, err := ParseDSN("root@tcp(" + server.addr() + ")/test")
+	assert.NoError(t, err)
+	configure(cfg)
+	return NewDBFromConfig(cfg), server
+}
+
+func TestBoundedPoolDialsUpToMaxOpen(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 2
+		cfg.MaxOpen = 2
+	})
+	defer server.close()
+	defer db.Close()
+
+	conn1, err := db.GetConn()
+	assert.NoError(t, err)
+	conn2, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.Equal(t, db.open, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = db.GetConnContext(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Len(t, db.waiters, 0)
+
+	assert.NoError(t, db.PutConn(conn1))
+	assert.NoError(t, db.PutConn(conn2))
+	assert.Equal(t, db.open, 2)
+	assert.Len(t, db.conns, 2)
+}
+
+func TestBoundedPoolHandsOverReturnedConnection(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 1
+		cfg.MaxOpen = 1
+	})
+	defer server.close()
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	acquired := make(chan *Conn)
+	go func() {
+		c, _ := db.GetConnContext(context.Background())
+		acquired <- c
+	}()
+
+	waitForWaiters(db, 1)
+	assert.NoError(t, db.PutConn(conn))
+	assert.True(t, <-acquired == conn)
+	assert.Equal(t, db.open, 1)
+}
+
+func TestBoundedPoolFIFO(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 1
+		cfg.MaxOpen = 1
+	})
+	defer server.close()
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	order := make(chan int, 3)
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := db.GetConnContext(context.Background())
+			if err != nil {
+				return
+			}
+			order <- i
+			db.PutConn(c)
+		}(i)
+		waitForWaiters(db, i)
+	}
+
+	assert.NoError(t, db.PutConn(conn))
+	assert.Equal(t, <-order, 1)
+	assert.Equal(t, <-order, 2)
+	assert.Equal(t, <-order, 3)
+	wg.Wait()
+}
+
+func TestBoundedPoolDiscardedConnectionFreesSlot(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 1
+		cfg.MaxOpen = 1
+	})
+	defer server.close()
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	acquired := make(chan *Conn)
+	go func() {
+		c, _ := db.GetConnContext(context.Background())
+		acquired <- c
+	}()
+
+	waitForWaiters(db, 1)
+	conn.Close()
+	assert.NoError(t, db.PutConn(conn))
+	assert.NoError(t, db.PutConn(conn)) // slot is freed only once
+
+	newConn := <-acquired
+	assert.NotNil(t, newConn)
+	assert.True(t, newConn != conn)
+	assert.True(t, newConn.valid)
+	assert.Equal(t, db.open, 1)
+}
+
+func TestBoundedPoolCloseWakesWaiters(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 1
+		cfg.MaxOpen = 1
+	})
+	defer server.close()
+
+	_, err := db.GetConn()
+	assert.NoError(t, err)
+
+	errs := make(chan error)
+	go func() {
+		_, err := db.GetConnContext(context.Background())
+		errs <- err
+	}()
+
+	waitForWaiters(db, 1)
+	db.Close()
+	assert.Equal(t, <-errs, ErrClosedDB)
+
+	_, err = db.GetConn()
+	assert.Equal(t, err, ErrClosedDB)
+}
+
+func TestBoundedPoolDialErrorFreesSlot(t *testing.T) {
+	cfg, err := ParseDSN("root@tcp(127.0.0.1:1)/test?maxOpen=1")
+	assert.NoError(t, err)
+	db := NewDBFromConfig(cfg)
+
+	_, err = db.GetConn()
+	assert.Error(t, err)
+	assert.Equal(t, db.open, 0)
+}
+
+func waitForWaiters(db *DB, n int) {
+	for {
+		db.mu.Lock()
+		waiting := len(db.waiters)
+		db.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGetConnPingsIdleConnection(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test?pingIdle=1ns", 1, 0)
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+
+	pinged, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.True(t, pinged == conn)
+	assert.Equal(t, server.receivedCommands(), []byte{comPing})
+}
+
+func TestGetConnDoesntPingRecentlyUsedConnection(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test?pingIdle=1h", 1, 0)
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+
+	_, err = db.GetConn()
+	assert.NoError(t, err)
+	assert.Empty(t, server.receivedCommands())
+}
+
+func TestGetConnReplacesDeadConnection(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test?pingIdle=1ns&maxOpen=1", 1, 0)
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	server.dropConns()
+
+	newConn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.True(t, newConn != conn)
+	assert.True(t, conn.closed)
+	assert.True(t, newConn.valid)
+	assert.Equal(t, db.open, 1)
+}
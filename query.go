@@ -1,28 +1,47 @@
 package mysqldriver
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/pubnative/mysqlproto-go"
 )
 
-// Rows represents result set of SELECT query
+// Rows represents result set of SELECT query.
+// Result set of the prepared statement is encoded with the binary protocol.
+// In this case numeric values are decoded without parsing strings.
 type Rows struct {
+	conn      *Conn
 	resultSet mysqlproto.ResultSet
 	packet    []byte
 	offset    uint64
 	eof       bool
+	empty     bool   // statement didn't return result set
+	more      bool   // more result sets follow (see func (*Rows) NextResultSet)
+	binary    bool   // result set is encoded with the binary protocol
+	buf       []byte // values of the binary row converted to text
+	watched   bool   // result set is bound to the context
+	transcode bool   // latin1 values are converted into UTF-8
 
 	errRead  error // error reading from the stream
 	errParse error // error parsing the value
 
+	trace *queryTrace // see DB.QueryHook
+	db    *DB         // pool which gets the connection back once all rows are read
+
 	columns     map[string]columnValue
 	readColumns int
+	columnInfo  []ColumnInfo // see func (*Rows) Columns
+	plan        *scanPlan    // see func (*Rows) ScanStruct
+
+	outs []interface{} // destinations of OUT parameters (see func (*Conn) Call)
 }
 
 type columnValue struct {
-	data []byte
-	null bool
+	data   []byte
+	null   bool
+	latin1 bool               // value has to be converted into UTF-8
+	column *mysqlproto.Column // set when value is encoded with the binary protocol
 }
 
 // Next moves cursor to the next unread row.
@@ -63,19 +82,41 @@ func (r *Rows) Next() bool {
 		return false
 	}
 
-	packet, err := r.resultSet.Row()
-	if err != nil {
-		r.errRead = err
-		return false
+	var packet []byte
+	if !r.empty {
+		var err error
+		if packet, err = r.conn.readRow(); err != nil {
+			r.errRead = err
+			r.finish()
+			return false
+		}
 	}
 
 	if packet == nil {
 		r.eof = true
+		if r.conn.moreResults() {
+			if r.conn.multiStatements || r.outs != nil {
+				// stay bound to the context until the last result set is read
+				r.more = true
+				return false
+			}
+			// more result sets aren't expected by the caller,
+			// e.g. status of the procedure executed with CALL
+			r.errRead = r.conn.skipResults()
+		}
+		r.finish()
 		return false
 	} else {
 		r.packet = packet
 		r.offset = 0
+		if r.binary {
+			r.offset = binaryRowValuesOffset(len(r.resultSet.Columns))
+			r.buf = r.buf[:0]
+		}
 		r.readColumns = 0
+		if r.trace != nil {
+			r.trace.info.Rows++
+		}
 		return true
 	}
 }
@@ -89,26 +130,14 @@ func (r *Rows) Bytes() []byte {
 
 // NullBytes returns value as a slice of bytes
 // and NULL indicator. When value is NULL, second parameter is true.
-// All other type-specific functions are based on this one.
+// Values of the binary protocol (see func (*Stmt) Query)
+// are converted into their text representation.
 // NullBytes shouldn't be invoked after all columns are read.
 // Calling it after reading all values of the row
 // will return nil value with NULL flag
 func (r *Rows) NullBytes() ([]byte, bool) {
-	if r.readColumns == len(r.resultSet.Columns) {
-		return nil, true
-	}
-
-	value, offset, null := mysqlproto.ReadRowValue(r.packet, r.offset)
-	r.offset = offset
-
-	name := r.resultSet.Columns[r.readColumns].Name
-	r.columns[name] = columnValue{
-		data: value,
-		null: null,
-	}
-	r.readColumns += 1
-
-	return value, null
+	value := r.nextValue()
+	return r.bytes(value), value.null
 }
 
 // String returns value as a string.
@@ -121,8 +150,8 @@ func (r *Rows) String() string {
 // NullString returns string as a value and
 // NULL indicator. When value is NULL, second parameter is true.
 func (r *Rows) NullString() (string, bool) {
-	data, null := r.NullBytes()
-	return string(data), null
+	value := r.nextValue()
+	return r.string(value), value.null
 }
 
 // Int returns value as an int.
@@ -139,12 +168,12 @@ func (r *Rows) Int() int {
 // NullInt method uses strconv.Atoi to convert string into int.
 // (see https://golang.org/pkg/strconv/#Atoi)
 func (r *Rows) NullInt() (int, bool) {
-	str, null := r.NullBytes()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := atoi(str)
+	num, err := r.atoi(value)
 	if err != nil {
 		r.errParse = err
 	}
@@ -166,12 +195,12 @@ func (r *Rows) Int8() int8 {
 // NullInt8 method uses strconv.ParseInt to convert string into int8.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r *Rows) NullInt8() (int8, bool) {
-	str, null := r.NullString()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 8)
+	num, err := r.parseInt(value, 8)
 	if err != nil {
 		r.errParse = err
 	}
@@ -193,12 +222,12 @@ func (r *Rows) Int16() int16 {
 // NullInt16 method uses strconv.ParseInt to convert string into int16.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r *Rows) NullInt16() (int16, bool) {
-	str, null := r.NullString()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 16)
+	num, err := r.parseInt(value, 16)
 	if err != nil {
 		r.errParse = err
 	}
@@ -220,12 +249,12 @@ func (r *Rows) Int32() int32 {
 // NullInt32 method uses strconv.ParseInt to convert string into int32.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r *Rows) NullInt32() (int32, bool) {
-	str, null := r.NullString()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 32)
+	num, err := r.parseInt(value, 32)
 	if err != nil {
 		r.errParse = err
 	}
@@ -247,12 +276,12 @@ func (r *Rows) Int64() int64 {
 // NullInt64 method uses strconv.ParseInt to convert string into int64.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r *Rows) NullInt64() (int64, bool) {
-	str, null := r.NullString()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 64)
+	num, err := r.parseInt(value, 64)
 	if err != nil {
 		r.errParse = err
 	}
@@ -274,12 +303,12 @@ func (r *Rows) Float32() float32 {
 // NullFloat32 method uses strconv.ParseFloat to convert string into float32.
 // (see https://golang.org/pkg/strconv/#ParseFloat)
 func (r *Rows) NullFloat32() (float32, bool) {
-	str, null := r.NullString()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseFloat(str, 32)
+	num, err := r.parseFloat(value, 32)
 	if err != nil {
 		r.errParse = err
 	}
@@ -301,12 +330,12 @@ func (r *Rows) Float64() float64 {
 // NullFloat64 method uses strconv.ParseFloat to convert string into float64.
 // (see https://golang.org/pkg/strconv/#ParseFloat)
 func (r *Rows) NullFloat64() (float64, bool) {
-	str, null := r.NullString()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseFloat(str, 64)
+	num, err := r.parseFloat(value, 64)
 	if err != nil {
 		r.errParse = err
 	}
@@ -328,12 +357,12 @@ func (r *Rows) Bool() bool {
 // NullBool method uses strconv.ParseBool to convert string into bool.
 // (see https://golang.org/pkg/strconv/#ParseBool)
 func (r *Rows) NullBool() (bool, bool) {
-	str, null := r.NullBytes()
-	if null {
+	value := r.nextValue()
+	if value.null {
 		return false, true
 	}
 
-	b, err := parseBool(str)
+	b, err := r.parseBool(value)
 	if err != nil {
 		r.errParse = err
 	}
@@ -364,23 +393,29 @@ func (r *Rows) LastError() error {
 // Query function is used only for SELECT query.
 // For all other queries and commands see func (c Conn) Exec
 func (c *Conn) Query(sql string) (*Rows, error) {
-	req := mysqlproto.ComQueryRequest([]byte(sql))
+	trace := c.startQuery(context.Background(), sql, nil)
+	rows, err := c.query(mysqlproto.ComQueryRequest([]byte(sql)))
+	return trace.attach(c, rows, c.queryError(sql, err))
+}
+
+// query sends COM_QUERY packet and reads the result set
+func (c *Conn) query(req []byte) (*Rows, error) {
 	if _, err := c.conn.Write(req); err != nil {
 		c.valid = false
 		return nil, err
 	}
 
-	resultSet, err := mysqlproto.ComQueryResponse(c.conn)
+	columns, _, err := c.readResultSet()
 	if err != nil {
-		if _, ok := err.(mysqlproto.ERRPacket); !ok {
-			c.valid = false
-		}
 		return nil, err
 	}
 
 	rows := &Rows{
-		resultSet: resultSet,
-		columns:   make(map[string]columnValue, len(resultSet.Columns)),
+		conn:      c,
+		resultSet: mysqlproto.ResultSet{Columns: columns},
+		empty:     len(columns) == 0,
+		transcode: c.transcodeLatin1,
+		columns:   make(map[string]columnValue, len(columns)),
 	}
 	return rows, nil
 }
@@ -391,26 +426,53 @@ func (c *Conn) Query(sql string) (*Rows, error) {
 //	if err == nil {
 //  	return nil // query was performed successfully
 //  }
-//  if errPacket, ok := err.(mysqlproto.ERRPacket); ok {
+//  var errPacket mysqlproto.ERRPacket
+//  if errors.As(err, &errPacket) {
 //  	return errPacket // retrieve more information about the error
 //  } else {
 //  	return err // generic error
 //  }
+// Failed query returns *Error which can be matched with
+// classes of errors like ErrDuplicateKey (see func IsRetryable).
 func (c *Conn) Exec(sql string) (mysqlproto.OKPacket, error) {
-	req := mysqlproto.ComQueryRequest([]byte(sql))
+	trace := c.startQuery(context.Background(), sql, nil)
+	pkt, err := c.exec(mysqlproto.ComQueryRequest([]byte(sql)))
+	err = c.queryError(sql, err)
+	trace.endExec(c, pkt, err)
+	return pkt, err
+}
+
+// exec sends COM_QUERY packet and reads OK_PACKET
+func (c *Conn) exec(req []byte) (mysqlproto.OKPacket, error) {
 	if _, err := c.conn.Write(req); err != nil {
 		c.valid = false
 		return mysqlproto.OKPacket{}, err
 	}
 
+	return c.readOK()
+}
+
+func (c *Conn) readOK() (mysqlproto.OKPacket, error) {
 	packet, err := c.conn.NextPacket()
 	if err != nil {
 		c.valid = false
 		return mysqlproto.OKPacket{}, err
 	}
+	return c.parseOK(packet)
+}
+
+// parseOK parses the response to the statement which doesn't return rows.
+// LOAD DATA LOCAL INFILE statement requests the local file instead.
+func (c *Conn) parseOK(packet mysqlproto.Packet) (mysqlproto.OKPacket, error) {
+	if packet.Payload[0] == localInfileRequest {
+		return c.sendLocalFile(string(packet.Payload[1:]), packet.SequenceID+1)
+	}
 
 	if packet.Payload[0] == mysqlproto.OK_PACKET {
 		pkt, err := mysqlproto.ParseOKPacket(packet.Payload, c.conn.CapabilityFlags)
+		if err == nil {
+			c.status = pkt.StatusFlags
+		}
 		return pkt, err
 	} else {
 		pkt, err := mysqlproto.ParseERRPacket(packet.Payload, c.conn.CapabilityFlags)
@@ -421,3 +483,103 @@ func (c *Conn) Exec(sql string) (mysqlproto.OKPacket, error) {
 		}
 	}
 }
+
+// nextValue reads the next column's value of the row
+func (r *Rows) nextValue() columnValue {
+	if r.readColumns == len(r.resultSet.Columns) {
+		return columnValue{null: true}
+	}
+
+	var value columnValue
+	if r.binary {
+		value = r.nextBinaryValue()
+	} else {
+		data, offset, null := mysqlproto.ReadRowValue(r.packet, r.offset)
+		r.offset = offset
+		value = columnValue{data: data, null: null}
+	}
+
+	column := &r.resultSet.Columns[r.readColumns]
+	if r.transcode && latin1Collations[column.CharacterSet] {
+		value.latin1 = true
+	}
+	r.columns[column.Name] = value
+	r.readColumns += 1
+
+	return value
+}
+
+func (r *Rows) nextBinaryValue() columnValue {
+	if binaryValueIsNULL(r.packet, r.readColumns) {
+		return columnValue{data: []byte{}, null: true}
+	}
+
+	column := &r.resultSet.Columns[r.readColumns]
+	data, offset := readBinaryValue(r.packet, r.offset, ColumnType(column.ColumnType))
+	r.offset = offset
+
+	return columnValue{data: data, column: column}
+}
+
+// bytes returns value in the representation of the text protocol
+func (r *Rows) bytes(value columnValue) []byte {
+	if value.null || value.column == nil || binaryValueIsText(ColumnType(value.column.ColumnType)) {
+		return value.data
+	}
+
+	// r.buf isn't truncated until the next row,
+	// so previously returned values stay valid
+	start := len(r.buf)
+	r.buf = appendBinaryValueAsText(r.buf, value.data, *value.column)
+	return r.buf[start:len(r.buf):len(r.buf)]
+}
+
+// string returns value as a string converting latin1 into UTF-8 if needed
+func (r *Rows) string(value columnValue) string {
+	if value.latin1 {
+		return latin1ToString(r.bytes(value))
+	}
+	return string(r.bytes(value))
+}
+
+func (r *Rows) atoi(value columnValue) (int, error) {
+	if value.column != nil {
+		if num, unsigned, ok := binaryInt(value.data, value.column); ok {
+			n, err := checkIntRange("Atoi", num, unsigned, strconv.IntSize)
+			return int(n), err
+		}
+	}
+	return atoi(r.bytes(value))
+}
+
+func (r *Rows) parseInt(value columnValue, bitSize int) (int64, error) {
+	if value.column != nil {
+		if num, unsigned, ok := binaryInt(value.data, value.column); ok {
+			return checkIntRange("ParseInt", num, unsigned, bitSize)
+		}
+	}
+	return strconv.ParseInt(string(r.bytes(value)), 10, bitSize)
+}
+
+func (r *Rows) parseFloat(value columnValue, bitSize int) (float64, error) {
+	if value.column != nil {
+		if num, ok := binaryFloat(value.data, value.column); ok {
+			return num, nil
+		}
+	}
+	return strconv.ParseFloat(string(r.bytes(value)), bitSize)
+}
+
+func (r *Rows) parseBool(value columnValue) (bool, error) {
+	if value.column != nil {
+		if num, _, ok := binaryInt(value.data, value.column); ok {
+			switch num {
+			case 0:
+				return false, nil
+			case 1:
+				return true, nil
+			}
+		}
+	}
+	return parseBool(r.bytes(value))
+}
@@ -1,6 +1,7 @@
 package mysqldriver
 
 import (
+	"errors"
 	"strconv"
 	"testing"
 	"time"
@@ -14,8 +15,10 @@ func TestQueryError(t *testing.T) {
 		_, err := conn.Query("SELECT * FROM unknown_table")
 		assert.NotNil(t, err)
 		assert.True(t, conn.valid)
-		pkt, ok := err.(mysqlproto.ERRPacket)
-		assert.True(t, ok)
+		assert.Equal(t, err.(*Error).SQL, "SELECT * FROM unknown_table")
+		assert.True(t, errors.Is(err, ErrUnknownTable))
+		var pkt mysqlproto.ERRPacket
+		ok := errors.As(err, &pkt)
 		assert.True(t, ok)
 		assert.Equal(t, pkt.Header, mysqlproto.ERR_PACKET)
 		assert.Equal(t, pkt.ErrorCode, mysqlproto.ER_NO_SUCH_TABLE)
@@ -337,7 +340,9 @@ func TestExecInsertError(t *testing.T) {
 		_, err := conn.Exec(`INSERT INTO people(firstname)`)
 		assert.NotNil(t, err)
 		assert.True(t, conn.valid)
-		pkt, ok := err.(mysqlproto.ERRPacket)
+		assert.True(t, errors.Is(err, ErrSyntax))
+		var pkt mysqlproto.ERRPacket
+		ok := errors.As(err, &pkt)
 		assert.True(t, ok)
 		assert.Equal(t, pkt.Header, mysqlproto.ERR_PACKET)
 		assert.Equal(t, pkt.ErrorCode, mysqlproto.ER_PARSE_ERROR)
@@ -0,0 +1,127 @@
+package mysqldriver
+
+import (
+	"context"
+	"time"
+)
+
+// maxReapInterval is the longest interval between checks
+// of idle connections made by the reaper
+const maxReapInterval = time.Second
+
+// reaped reports whether the pool needs the reaper
+func (cfg *Config) reaped() bool {
+	return cfg.MinIdle > 0 || cfg.MaxIdleTime > 0 || cfg.MaxLifetime > 0
+}
+
+// reapInterval returns the interval which is short
+// enough to close stale connections in time
+func (cfg *Config) reapInterval() time.Duration {
+	interval := maxReapInterval
+	for _, timeout := range []time.Duration{cfg.MaxIdleTime, cfg.MaxLifetime} {
+		if timeout > 0 && timeout/2 < interval {
+			interval = timeout / 2
+		}
+	}
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return interval
+}
+
+// stale reports whether the connection is idle or open for too long.
+// Stale connection is counted in stats of the pool as it has to be closed.
+func (db *DB) stale(conn *Conn, now time.Time) bool {
+	switch {
+	case db.config.MaxLifetime > 0 && now.Sub(conn.createdAt) >= db.config.MaxLifetime:
+		db.stats.inc(&db.stats.maxLifetimeClosed)
+		return true
+	case db.config.MaxIdleTime > 0 && now.Sub(conn.idleSince) >= db.config.MaxIdleTime:
+		db.stats.inc(&db.stats.maxIdleTimeClosed)
+		return true
+	}
+	return false
+}
+
+// startReaper starts the reaper unless DB is already closed
+func (db *DB) startReaper() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	db.stopReaper = cancel
+	db.reaperDone = make(chan struct{})
+	go db.reap(ctx, db.reaperDone)
+}
+
+// reap periodically closes stale idle connections and establishes
+// new ones until there are Config.MinIdle idle connections
+func (db *DB) reap(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(db.config.reapInterval())
+	defer ticker.Stop()
+	for {
+		db.closeStale(time.Now())
+		db.warmUp(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeStale checks every idle connection once and closes stale ones
+func (db *DB) closeStale(now time.Time) {
+	for n := len(db.conns); n > 0; n-- {
+		var conn *Conn
+		select {
+		case conn = <-db.conns:
+		default:
+			return
+		}
+
+		if db.stale(conn, now) {
+			db.discard(conn)
+			continue
+		}
+		if db.handOver(conn) {
+			continue
+		}
+		select {
+		case db.conns <- conn:
+		default:
+			db.discard(conn)
+		}
+	}
+}
+
+// warmUp establishes idle connections until there are
+// Config.MinIdle of them, but no more than the pool size
+func (db *DB) warmUp(ctx context.Context) {
+	minIdle := db.config.MinIdle
+	if minIdle > cap(db.conns) {
+		minIdle = cap(db.conns)
+	}
+
+	for n := minIdle - len(db.conns); n > 0; n-- {
+		var conn *Conn
+		var err error
+		if db.config.MaxOpen > 0 {
+			if !db.reserveSlot() {
+				return
+			}
+			conn, err = db.dialSlot(ctx)
+		} else if conn, err = db.dial(ctx); err != nil && conn != nil {
+			conn.Close()
+		}
+		if err != nil {
+			return
+		}
+		db.PutConn(conn)
+	}
+}
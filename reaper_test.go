@@ -0,0 +1,141 @@
+package mysqldriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitFor polls the condition until it's met or a second passes
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition isn't met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReapInterval(t *testing.T) {
+	assert.Equal(t, (&Config{MinIdle: 1}).reapInterval(), time.Second)
+	assert.Equal(t, (&Config{MaxIdleTime: time.Minute}).reapInterval(), time.Second)
+	assert.Equal(t, (&Config{MaxIdleTime: time.Minute, MaxLifetime: 100 * time.Millisecond}).reapInterval(), 50*time.Millisecond)
+	assert.Equal(t, (&Config{MaxLifetime: time.Nanosecond}).reapInterval(), time.Millisecond)
+}
+
+func TestGetConnSkipsStaleConnection(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MaxLifetime = 20 * time.Millisecond
+	})
+	defer server.close()
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+
+	time.Sleep(30 * time.Millisecond)
+	newConn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.True(t, newConn != conn)
+}
+
+func TestPutConnDiscardsExpiredConnection(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MaxLifetime = 20 * time.Millisecond
+	})
+	defer server.close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, db.PutConn(conn))
+	assert.True(t, conn.closed)
+	assert.Len(t, db.conns, 0)
+	assert.Nil(t, db.Close())
+}
+
+func TestReaperClosesIdleConnections(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MaxIdleTime = 20 * time.Millisecond
+	})
+	defer server.close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+
+	waitFor(t, func() bool { return len(db.conns) == 0 })
+	assert.Nil(t, db.Close())
+	assert.True(t, conn.closed)
+}
+
+func TestReaperWarmsUpIdleConnections(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MinIdle = 5
+	})
+	defer server.close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	waitFor(t, func() bool { return len(db.conns) == 3 }) // limited by pool size
+
+	assert.NoError(t, db.PutConn(conn)) // pool is full
+	assert.True(t, conn.closed)
+	assert.Nil(t, db.Close())
+}
+
+func TestReaperRespectsMaxOpen(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MinIdle = 3
+		cfg.MaxOpen = 2
+	})
+	defer server.close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	waitFor(t, func() bool { return len(db.conns) == 1 })
+
+	assert.NoError(t, db.PutConn(conn))
+	assert.Nil(t, db.Close())
+	assert.Equal(t, db.open, 0)
+}
+
+func TestCloseStopsReaper(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MinIdle = 1
+	})
+	defer server.close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+
+	assert.Nil(t, db.Close())
+	_, more := <-db.reaperDone
+	assert.False(t, more)
+
+	_, err = db.GetConn()
+	assert.Equal(t, err, ErrClosedDB)
+}
+
+func TestCloseWithoutReaper(t *testing.T) {
+	db, server := newFakeDB(t, func(cfg *Config) {
+		cfg.Pool = 3
+		cfg.MinIdle = 1
+	})
+	defer server.close()
+
+	assert.Nil(t, db.Close())
+	_, err := db.GetConn()
+	assert.Equal(t, err, ErrClosedDB)
+	assert.Nil(t, db.reaperDone)
+}
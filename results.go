@@ -0,0 +1,271 @@
+package mysqldriver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// NextResultSet moves to the next result set of the multi-statement
+// query (see Config.MultiStatements) or of the stored procedure
+// (see func (*Conn) Call). Other queries, e.g. CALL executed without
+// multi-statements, skip the following result sets once the first
+// one is read, so the connection is released by Next.
+// Unread rows of the current result set are skipped. Statements
+// which don't return rows, e.g. INSERT, are represented by result sets
+// without columns and rows. It returns false when there are no more
+// result sets or an error occurred (see func (*Rows) LastError).
+//  rows, _ := conn.Query("SELECT name FROM dogs; SELECT name FROM cats")
+//  for rows.Next() {
+//  	rows.String() // dog's name
+//  }
+//  rows.NextResultSet()
+//  for rows.Next() {
+//  	rows.String() // cat's name
+//  }
+// All result sets must be read before performing another query
+// (see func (*Rows) Close), otherwise the connection is discarded by the pool.
+func (r *Rows) NextResultSet() bool {
+	for r.Next() {
+		// skip unread rows
+	}
+	if !r.more {
+		return false
+	}
+
+	r.more = false
+	columns, _, err := r.conn.readResultSet()
+	if err != nil {
+		r.errRead = err
+		r.finish()
+		return false
+	}
+
+	r.reset(columns)
+	if err = r.readOutParams(); err != nil {
+		r.errRead = err
+		r.finish()
+		return false
+	}
+	if r.outs != nil && r.empty {
+		// OK_PACKET with the status of the procedure
+		// isn't a result set (see func (*Conn) Call)
+		r.Next()
+		return false
+	}
+	return true
+}
+
+// reset moves rows to the result set with the given columns
+func (r *Rows) reset(columns []mysqlproto.Column) {
+	r.resultSet = mysqlproto.ResultSet{Columns: columns}
+	r.empty = len(columns) == 0
+	r.eof = false
+	r.packet = nil
+	r.columns = make(map[string]columnValue, len(columns))
+	r.readColumns = 0
+	r.columnInfo = nil
+	r.plan = nil
+}
+
+// Close skips the rest of rows and result sets, so the connection
+// can perform another query. It returns the error of reading them.
+//  rows, err := conn.Call("find_dogs")
+//  if err != nil {
+//  	// handle error
+//  }
+//  defer rows.Close()
+func (r *Rows) Close() error {
+	for r.NextResultSet() {
+	}
+	return r.LastError()
+}
+
+// ExecMulti executes multi-statement query (see Config.MultiStatements)
+// and returns OK_PACKET of every statement. Rows of the statements
+// returning result sets are skipped, such statements are represented by
+// OK_PACKET holding the status of the server. When a statement fails,
+// following statements aren't executed and packets of the preceding
+// ones are returned together with the error.
+//  pkts, err := conn.ExecMulti("INSERT INTO dogs(name) VALUES ('Rex'); DELETE FROM cats")
+//  if err != nil {
+//  	// handle error
+//  }
+//  pkts[0].LastInsertID // ID of the dog
+//  pkts[1].AffectedRows // number of deleted cats
+func (c *Conn) ExecMulti(sql string) ([]mysqlproto.OKPacket, error) {
+	trace := c.startQuery(context.Background(), sql, nil)
+	pkts, err := c.execMulti(mysqlproto.ComQueryRequest([]byte(sql)))
+	err = c.queryError(sql, err)
+
+	var total mysqlproto.OKPacket
+	for _, pkt := range pkts {
+		total.AffectedRows += pkt.AffectedRows
+	}
+	trace.endExec(c, total, err)
+	return pkts, err
+}
+
+func (c *Conn) execMulti(req []byte) ([]mysqlproto.OKPacket, error) {
+	if _, err := c.conn.Write(req); err != nil {
+		c.valid = false
+		return nil, err
+	}
+
+	var pkts []mysqlproto.OKPacket
+	for {
+		columns, pkt, err := c.readResultSet()
+		if err != nil {
+			return pkts, err
+		}
+		if len(columns) > 0 {
+			if err = c.skipRows(); err != nil {
+				return pkts, err
+			}
+			pkt = mysqlproto.OKPacket{Header: mysqlproto.EOF_PACKET, StatusFlags: c.status}
+		}
+		pkts = append(pkts, pkt)
+		if !c.moreResults() {
+			return pkts, nil
+		}
+	}
+}
+
+// readResultSet reads definitions of the columns of the result set.
+// Statement which doesn't return rows responds with OK_PACKET,
+// in this case there are no columns.
+func (c *Conn) readResultSet() ([]mysqlproto.Column, mysqlproto.OKPacket, error) {
+	packet, err := c.conn.NextPacket()
+	if err != nil {
+		c.valid = false
+		return nil, mysqlproto.OKPacket{}, err
+	}
+
+	switch packet.Payload[0] {
+	case mysqlproto.OK_PACKET:
+		pkt, err := mysqlproto.ParseOKPacket(packet.Payload, c.conn.CapabilityFlags)
+		if err == nil {
+			c.status = pkt.StatusFlags
+		}
+		return nil, pkt, err
+	case mysqlproto.ERR_PACKET:
+		c.status &^= serverMoreResultsExists // server stops executing statements
+		return nil, mysqlproto.OKPacket{}, handleOK(packet.Payload, c.conn.CapabilityFlags)
+	case localInfileRequest:
+		pkt, err := c.sendLocalFile(string(packet.Payload[1:]), packet.SequenceID+1)
+		return nil, pkt, err
+	}
+
+	count, _ := readLenEncInt(packet.Payload, 0)
+	columns := make([]mysqlproto.Column, count)
+	for i := range columns {
+		if packet, err = c.conn.NextPacket(); err != nil {
+			c.valid = false
+			return nil, mysqlproto.OKPacket{}, err
+		}
+		if columns[i], err = parseColumn(packet.Payload); err != nil {
+			c.valid = false
+			return nil, mysqlproto.OKPacket{}, err
+		}
+	}
+
+	// EOF_PACKET after column definitions holds the status
+	// telling whether the result set contains OUT parameters
+	if packet, err = c.conn.NextPacket(); err != nil {
+		c.valid = false
+		return nil, mysqlproto.OKPacket{}, err
+	}
+	if len(packet.Payload) >= 5 {
+		c.status = readUint16(packet.Payload[3:])
+	}
+	return columns, mysqlproto.OKPacket{}, nil
+}
+
+// parseColumn parses Protocol::ColumnDefinition41
+// (see https://dev.mysql.com/doc/internals/en/com-query-response.html#column-definition)
+func parseColumn(payload []byte) (column mysqlproto.Column, err error) {
+	defer func() {
+		// lengths of the strings exceed the payload
+		if e := recover(); e != nil {
+			err = fmt.Errorf("mysqldriver: broken column definition. Payload: %x", payload)
+		}
+	}()
+
+	var fields [6][]byte // catalog, schema, table, org_table, name, org_name
+	var offset uint64
+	for i := range fields {
+		fields[i], offset, _ = mysqlproto.ReadRowValue(payload, offset)
+	}
+	offset++ // length of fixed-length fields, always 0x0c
+	_ = payload[offset+9]
+
+	column.Catalog = string(fields[0])
+	column.Schema = string(fields[1])
+	column.Table = string(fields[2])
+	column.OrgTable = string(fields[3])
+	column.Name = string(fields[4])
+	column.OrgName = string(fields[5])
+	column.CharacterSet = readUint16(payload[offset:])
+	column.ColumnLength = readUint32(payload[offset+2:])
+	column.ColumnType = payload[offset+6]
+	column.Flags = readUint16(payload[offset+7:])
+	column.Decimals = payload[offset+9]
+	return column, nil
+}
+
+// readRow reads the next row of the result set. It returns nil
+// when the result set ends. Status of the server is taken
+// from EOF_PACKET, so it's known whether more results follow.
+func (c *Conn) readRow() ([]byte, error) {
+	packet, err := c.conn.NextPacket()
+	if err != nil {
+		c.valid = false
+		return nil, err
+	}
+
+	payload := packet.Payload
+	switch {
+	case payload[0] == mysqlproto.EOF_PACKET && len(payload) < 9:
+		if len(payload) >= 5 {
+			c.status = readUint16(payload[3:])
+		}
+		return nil, nil
+	case payload[0] == mysqlproto.ERR_PACKET:
+		c.status &^= serverMoreResultsExists
+		return nil, handleOK(payload, c.conn.CapabilityFlags)
+	}
+	return payload, nil
+}
+
+// skipRows reads the rest of the result set
+func (c *Conn) skipRows() error {
+	for {
+		row, err := c.readRow()
+		if row == nil {
+			return err
+		}
+	}
+}
+
+// skipResults reads the rest of result sets
+func (c *Conn) skipResults() error {
+	for c.moreResults() {
+		columns, _, err := c.readResultSet()
+		if err != nil {
+			return err
+		}
+		if len(columns) > 0 {
+			if err = c.skipRows(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moreResults reports whether the server has more results
+// of the multi-statement query or the stored procedure
+func (c *Conn) moreResults() bool {
+	return c.status&serverMoreResultsExists != 0
+}
@@ -0,0 +1,187 @@
+package mysqldriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func columnPacket(name string) []byte {
+	var buf []byte
+	for _, s := range []string{"def", "test", "t", "t", name, name} {
+		buf = appendLenEncString(buf, s)
+	}
+	buf = append(buf, 0x0c)
+	buf = appendUint16(buf, 33)
+	buf = appendUint32(buf, 255)
+	buf = append(buf, byte(TypeVarString))
+	buf = appendUint16(buf, 0)
+	return append(buf, 0, 0, 0)
+}
+
+func eofPacket(status uint16) []byte {
+	return appendUint16([]byte{mysqlproto.EOF_PACKET, 0, 0}, status)
+}
+
+func okPacket(affectedRows, lastInsertID uint64, status uint16) []byte {
+	buf := appendLenEncInt([]byte{mysqlproto.OK_PACKET}, affectedRows)
+	buf = appendLenEncInt(buf, lastInsertID)
+	return appendUint16(appendUint16(buf, status), 0)
+}
+
+// resultSet returns packets of the result set with a single column
+func resultSet(column string, status uint16, values ...string) [][]byte {
+	packets := [][]byte{{1}, columnPacket(column), eofPacket(0)}
+	for _, value := range values {
+		packets = append(packets, appendLenEncString(nil, value))
+	}
+	return append(packets, eofPacket(status))
+}
+
+func multiResultsConn(t *testing.T, server *fakeServer) (*DB, *Conn, *fakeServer) {
+	startFakeServer(t, server)
+	db := NewDB("root@tcp("+server.addr()+")/test?multiStatements=true", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	return db, conn, server
+}
+
+func TestRowsNextResultSet(t *testing.T) {
+	packets := resultSet("dog", serverMoreResultsExists, "Rex", "Max")
+	packets = append(packets, okPacket(1, 7, serverMoreResultsExists))
+	packets = append(packets, resultSet("cat", 0, "Tom")...)
+	db, conn, server := multiResultsConn(t, &fakeServer{results: map[string][][]byte{
+		"SELECT dog FROM dogs; INSERT INTO cats VALUES ('Tom'); SELECT cat FROM cats": packets,
+	}})
+	defer server.close()
+	defer db.Close()
+
+	rows, err := conn.Query("SELECT dog FROM dogs; INSERT INTO cats VALUES ('Tom'); SELECT cat FROM cats")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.String(), "Rex")
+	// the rest of the rows is skipped
+
+	assert.True(t, rows.NextResultSet())
+	assert.Len(t, rows.Columns(), 0)
+	assert.False(t, rows.Next())
+
+	assert.True(t, rows.NextResultSet())
+	assert.Equal(t, rows.Columns()[0].Name, "cat")
+	assert.True(t, rows.Next())
+	assert.Equal(t, rows.String(), "Tom")
+	assert.False(t, rows.Next())
+	assert.False(t, rows.NextResultSet())
+	assert.NoError(t, rows.LastError())
+
+	// connection is in sync
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	assert.Len(t, db.conns, 1)
+}
+
+func TestRowsNextResultSetError(t *testing.T) {
+	errPacket := []byte{mysqlproto.ERR_PACKET, 0x7a, 0x04, '#', '4', '2', 'S', '0', '2', 'n', 'o', ' ', 't', 'a', 'b', 'l', 'e'}
+	packets := append(resultSet("dog", serverMoreResultsExists, "Rex"), errPacket)
+	db, conn, server := multiResultsConn(t, &fakeServer{results: map[string][][]byte{"SELECT 1; SELECT 2": packets}})
+	defer server.close()
+	defer db.Close()
+
+	rows, err := conn.Query("SELECT 1; SELECT 2")
+	assert.NoError(t, err)
+	assert.False(t, rows.NextResultSet())
+	assert.True(t, isClass(rows.LastError(), ErrUnknownTable))
+	assert.NoError(t, db.PutConn(conn))
+	assert.Len(t, db.conns, 1)
+}
+
+func TestPutConnDiscardsUnreadResultSets(t *testing.T) {
+	packets := append(resultSet("dog", serverMoreResultsExists, "Rex"), resultSet("cat", 0, "Tom")...)
+	db, conn, server := multiResultsConn(t, &fakeServer{results: map[string][][]byte{"SELECT 1; SELECT 2": packets}})
+	defer server.close()
+	defer db.Close()
+
+	rows, err := conn.Query("SELECT 1; SELECT 2")
+	assert.NoError(t, err)
+	for rows.Next() {
+	}
+	assert.NoError(t, db.PutConn(conn))
+	assert.Len(t, db.conns, 0)
+}
+
+func TestExecMulti(t *testing.T) {
+	packets := [][]byte{okPacket(1, 7, serverMoreResultsExists)}
+	packets = append(packets, resultSet("dog", serverMoreResultsExists, "Rex")...)
+	packets = append(packets, okPacket(3, 0, 0))
+	db, conn, server := multiResultsConn(t, &fakeServer{results: map[string][][]byte{"INSERT; SELECT; DELETE": packets}})
+	defer server.close()
+	defer db.Close()
+
+	pkts, err := conn.ExecMulti("INSERT; SELECT; DELETE")
+	assert.NoError(t, err)
+	assert.Len(t, pkts, 3)
+	assert.Equal(t, pkts[0].LastInsertID, uint64(7))
+	assert.Equal(t, pkts[1].Header, mysqlproto.EOF_PACKET)
+	assert.Equal(t, pkts[2].AffectedRows, uint64(3))
+
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+}
+
+func TestMultiStatementsCapability(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	cfg, err := ParseDSN("root@tcp(" + server.addr() + ")/test?multiStatements=true")
+	assert.NoError(t, err)
+
+	flags := mysqlproto.CLIENT_MULTI_STATEMENTS | mysqlproto.CLIENT_MULTI_RESULTS
+	conn, err := NewConnFromConfig(context.Background(), cfg)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, conn.conn.CapabilityFlags&flags, flags)
+}
+
+func TestParseColumnBroken(t *testing.T) {
+	_, err := parseColumn(columnPacket("dog")[:20])
+	assert.Error(t, err)
+}
+
+func TestDBQuerySkipsUnexpectedResultSets(t *testing.T) {
+	packets := append(resultSet("dog", serverMoreResultsExists, "Rex"), okPacket(0, 0, 0))
+	server := startFakeServer(t, &fakeServer{results: map[string][][]byte{"CALL dogs()": packets}})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test", 1, time.Duration(0))
+	defer db.Close()
+
+	rows, err := db.Query(context.Background(), "CALL dogs()")
+	assert.NoError(t, err)
+	for rows.Next() {
+		assert.Equal(t, rows.String(), "Rex")
+	}
+	assert.NoError(t, rows.LastError())
+	assert.False(t, rows.NextResultSet())
+
+	// connection is returned to the pool in sync
+	assert.Len(t, db.conns, 1)
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+}
+
+func TestRowsClose(t *testing.T) {
+	packets := append(resultSet("dog", serverMoreResultsExists, "Rex"), resultSet("cat", 0, "Tom")...)
+	db, conn, server := multiResultsConn(t, &fakeServer{results: map[string][][]byte{"SELECT 1; SELECT 2": packets}})
+	defer server.close()
+	defer db.Close()
+
+	rows, err := conn.Query("SELECT 1; SELECT 2")
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, db.PutConn(conn))
+	assert.Len(t, db.conns, 1)
+}
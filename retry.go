@@ -0,0 +1,130 @@
+package mysqldriver
+
+import (
+	"context"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// maxRetryBackoff limits the delay between retries
+const maxRetryBackoff = 10 * time.Second
+
+type idempotentKey struct{}
+
+// Idempotent marks queries of DB.Query and DB.Exec bound to the returned
+// context as safe to be repeated. Such queries are retried after
+// any retryable error (see func IsRetryable).
+//  ctx := mysqldriver.Idempotent(context.Background())
+//  _, err := db.Exec(ctx, "UPDATE dogs SET name = ? WHERE id = ?", "Rex", 1)
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// Query performs SELECT query bound to the context using a connection
+// of the pool (see func (*Conn) QueryContext). Placeholders of the query
+// are substituted when arguments are given (see func (*Conn) QueryArgs).
+// The connection is returned to the pool once all rows are read,
+// so rows must be always read till the end.
+//  rows, err := db.Query(ctx, "SELECT name FROM dogs WHERE id = ?", 1)
+//  if err != nil {
+//  	// handle error
+//  }
+//  for rows.Next() {
+//  	name := rows.String()
+//  }
+//
+// When the connection turns out to be broken before any byte
+// of the result is read, the query is retried with another
+// connection up to Config.MaxRetries times waiting Config.RetryBackoff
+// between attempts. Queries marked with func Idempotent are retried
+// after any retryable error.
+func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (*Rows, error) {
+	var rows *Rows
+	err := db.retry(ctx, func(conn *Conn) error {
+		var err error
+		if rows, err = conn.queryContext(ctx, sql, args); err == nil {
+			rows.db = db
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Exec executes the query bound to the context using a connection
+// of the pool (see func (*Conn) ExecContext) and returns the connection
+// to the pool. Placeholders of the query are substituted when arguments
+// are given (see func (*Conn) ExecArgs). Failed query is retried
+// the same way as by func (*DB) Query.
+//  okPacket, err := db.Exec(ctx, "DELETE FROM dogs WHERE id = ?", 1)
+func (db *DB) Exec(ctx context.Context, sql string, args ...interface{}) (mysqlproto.OKPacket, error) {
+	var pkt mysqlproto.OKPacket
+	err := db.retry(ctx, func(conn *Conn) error {
+		var err error
+		if pkt, err = conn.execContext(ctx, sql, args); err == nil {
+			db.PutConn(conn)
+		}
+		return err
+	})
+	return pkt, err
+}
+
+// retry calls do with connections of the pool until it succeeds or
+// the error can't be retried. Connection is returned to the pool
+// by do when it succeeds and by retry otherwise.
+func (db *DB) retry(ctx context.Context, do func(conn *Conn) error) error {
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool)
+	for attempt := 0; ; attempt++ {
+		conn, err := db.GetConnContext(ctx)
+		retryable := false
+		if err == nil {
+			read := conn.Stats().BytesRead
+			if err = do(conn); err == nil {
+				return nil
+			}
+			// nothing is read from the broken connection,
+			// so the query most likely wasn't received
+			broken := IsConnectionError(err) && conn.Stats().BytesRead == read
+			retryable = broken || idempotent && IsRetryable(err)
+			db.PutConn(conn)
+		} else {
+			if conn != nil {
+				conn.Close()
+			}
+			retryable = IsConnectionError(err)
+		}
+
+		if !retryable || attempt >= db.config.MaxRetries {
+			return err
+		}
+		if err := db.backoff(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff waits before the retry until the context is done
+func (db *DB) backoff(ctx context.Context, attempt int) error {
+	delay := db.config.RetryBackoff
+	for i := 0; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
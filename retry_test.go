@@ -0,0 +1,109 @@
+package mysqldriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// retrying configures the pool of a single connection
+// retrying failed queries without noticeable backoff
+func retrying(maxRetries int) func(cfg *Config) {
+	return func(cfg *Config) {
+		cfg.Pool = 1
+		cfg.MaxRetries = maxRetries
+		cfg.RetryBackoff = time.Millisecond
+	}
+}
+
+func TestDBExecRetriesBrokenConnection(t *testing.T) {
+	db, server := newFakeDB(t, retrying(1))
+	defer server.close()
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	server.dropConns()
+
+	_, err = db.Exec(context.Background(), "DO ?", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, db.Stats().Dials, int64(2))
+	assert.Len(t, db.conns, 1)
+}
+
+func TestDBExecWithoutRetries(t *testing.T) {
+	db, server := newFakeDB(t, retrying(0))
+	defer server.close()
+	defer db.Close()
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	server.dropConns()
+
+	_, err = db.Exec(context.Background(), "DO 1")
+	assert.True(t, IsConnectionError(err))
+	assert.Equal(t, db.Stats().Dials, int64(1))
+	assert.Len(t, db.conns, 0)
+}
+
+func TestDBExecDoesntRetryQueryError(t *testing.T) {
+	db, server := newFakeDB(t, retrying(3))
+	defer server.close()
+	defer db.Close()
+
+	_, err := db.Exec(Idempotent(context.Background()), "DO ?, ?", 1)
+	assert.Error(t, err)
+	assert.Equal(t, db.Stats().Dials, int64(1))
+	assert.Len(t, db.conns, 1)
+	assert.Len(t, server.receivedCommands(), 0)
+}
+
+func TestDBExecBackoffCanceled(t *testing.T) {
+	db, server := newFakeDB(t, retrying(1))
+	defer server.close()
+	defer db.Close()
+	db.config.RetryBackoff = time.Hour
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.NoError(t, db.PutConn(conn))
+	server.dropConns()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = db.Exec(ctx, "DO 1")
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
+
+func TestDBRetryBackoff(t *testing.T) {
+	db := NewDBFromConfig(&Config{RetryBackoff: 2 * time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	assert.Equal(t, db.backoff(ctx, 10), context.Canceled)
+	assert.True(t, time.Since(start) < time.Second)
+
+	db.config.RetryBackoff = 0
+	assert.NoError(t, db.backoff(context.Background(), 3))
+}
+
+func TestDBQueryReturnsConnection(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 1, time.Duration(0))
+	defer db.Close()
+
+	rows, err := db.Query(context.Background(), "SELECT ? UNION SELECT 2", 1)
+	assert.NoError(t, err)
+	assert.Len(t, db.conns, 0)
+	var values []int
+	for rows.Next() {
+		values = append(values, rows.Int())
+	}
+	assert.NoError(t, rows.LastError())
+	assert.Equal(t, values, []int{1, 2})
+	assert.Len(t, db.conns, 1)
+}
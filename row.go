@@ -1,9 +1,5 @@
 package mysqldriver
 
-import (
-	"strconv"
-)
-
 // Row reads the entire row.
 // This function is identical to read each column successively.
 //  rows, _ := conn.Query("SELECT id, name FROM people")
@@ -43,9 +39,13 @@ type Row struct {
 // and NULL indicator. When value is NULL, second parameter is true.
 //
 // IMPORTANT. This function panics if it can't find the column by the name.
-//
-// All other type-specific functions are based on this one.
+// All other type-specific functions panic the same way.
 func (r Row) NullBytes(col string) ([]byte, bool) {
+	value := r.value(col)
+	return r.rows.bytes(value), value.null
+}
+
+func (r Row) value(col string) columnValue {
 	column, ok := r.columns[col]
 	if !ok {
 		msg := `mysqldriver: column "` + col + `" doesn't exist.`
@@ -63,7 +63,7 @@ func (r Row) NullBytes(col string) ([]byte, bool) {
 		panic(msg)
 	}
 
-	return column.data, column.null
+	return column
 }
 
 // Bytes returns value as slice of bytes.
@@ -76,8 +76,8 @@ func (r Row) Bytes(col string) []byte {
 // NullString returns string as a value and
 // NULL indicator. When value is NULL, second parameter is true.
 func (r Row) NullString(col string) (string, bool) {
-	value, null := r.NullBytes(col)
-	return string(value), null
+	value := r.value(col)
+	return r.rows.string(value), value.null
 }
 
 // String returns value as a string.
@@ -92,12 +92,12 @@ func (r Row) String(col string) string {
 // NullInt method uses strconv.Atoi to convert string into int.
 // (see https://golang.org/pkg/strconv/#Atoi)
 func (r Row) NullInt(col string) (int, bool) {
-	value, null := r.NullBytes(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := atoi(value)
+	num, err := r.rows.atoi(value)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -119,12 +119,12 @@ func (r Row) Int(col string) int {
 // NullInt8 method uses strconv.ParseInt to convert string into int8.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r Row) NullInt8(col string) (int8, bool) {
-	str, null := r.NullString(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 8)
+	num, err := r.rows.parseInt(value, 8)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -146,12 +146,12 @@ func (r Row) Int8(col string) int8 {
 // NullInt16 method uses strconv.ParseInt to convert string into int16.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r Row) NullInt16(col string) (int16, bool) {
-	str, null := r.NullString(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 16)
+	num, err := r.rows.parseInt(value, 16)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -173,12 +173,12 @@ func (r Row) Int16(col string) int16 {
 // NullInt32 method uses strconv.ParseInt to convert string into int32.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r Row) NullInt32(col string) (int32, bool) {
-	str, null := r.NullString(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 32)
+	num, err := r.rows.parseInt(value, 32)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -200,12 +200,12 @@ func (r Row) Int32(col string) int32 {
 // NullInt64 method uses strconv.ParseInt to convert string into int64.
 // (see https://golang.org/pkg/strconv/#ParseInt)
 func (r Row) NullInt64(col string) (int64, bool) {
-	str, null := r.NullString(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseInt(str, 10, 64)
+	num, err := r.rows.parseInt(value, 64)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -227,12 +227,12 @@ func (r Row) Int64(col string) int64 {
 // NullFloat32 method uses strconv.ParseFloat to convert string into float32.
 // (see https://golang.org/pkg/strconv/#ParseFloat)
 func (r Row) NullFloat32(col string) (float32, bool) {
-	str, null := r.NullString(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseFloat(str, 32)
+	num, err := r.rows.parseFloat(value, 32)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -254,12 +254,12 @@ func (r Row) Float32(col string) float32 {
 // NullFloat64 method uses strconv.ParseFloat to convert string into float64.
 // (see https://golang.org/pkg/strconv/#ParseFloat)
 func (r Row) NullFloat64(col string) (float64, bool) {
-	str, null := r.NullString(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return 0, true
 	}
 
-	num, err := strconv.ParseFloat(str, 64)
+	num, err := r.rows.parseFloat(value, 64)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -281,12 +281,12 @@ func (r Row) Float64(col string) float64 {
 // NullBool method uses strconv.ParseBool to convert string into bool.
 // (see https://golang.org/pkg/strconv/#ParseBool)
 func (r Row) NullBool(col string) (bool, bool) {
-	str, null := r.NullBytes(col)
-	if null {
+	value := r.value(col)
+	if value.null {
 		return false, true
 	}
 
-	b, err := parseBool(str)
+	b, err := r.rows.parseBool(value)
 	if err != nil {
 		r.rows.errParse = err
 	}
@@ -0,0 +1,359 @@
+package mysqldriver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// scanKind is a type of the value the column is decoded into
+type scanKind byte
+
+const (
+	scanUnsupported scanKind = iota
+	scanSkip                 // column is read and dropped
+	scanBool
+	scanInt
+	scanInt8
+	scanInt16
+	scanInt32
+	scanInt64
+	scanUint
+	scanUint8
+	scanUint16
+	scanUint32
+	scanUint64
+	scanFloat32
+	scanFloat64
+	scanString
+	scanBytes
+)
+
+// scanPlans caches plans of func (*Rows) ScanStruct by the type
+// of the struct and columns of the result set
+var scanPlans sync.Map // scanPlanKey -> *scanPlan
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	columns string // names of the columns separated by zero byte
+}
+
+// scanPlan maps columns of the result set to fields of the struct
+type scanPlan struct {
+	typ    reflect.Type // pointer to the struct
+	fields []scanField  // field of every column
+}
+
+type scanField struct {
+	offset uintptr // offset of the field in the struct
+	kind   scanKind
+}
+
+var errScanStructRead = errors.New("mysqldriver: ScanStruct must be called before reading values of the row")
+
+// Scan reads the next len(dest) values of the row into the variables
+// pointed by dest. Supported variables are bool, integers, floats,
+// string and []byte including the types defined on top of them.
+// NULL value is stored as the zero value of the variable.
+//  rows, _ := conn.Query("SELECT id, name FROM dogs")
+//  for rows.Next() {
+//  	var id int64
+//  	var name string
+//  	if err := rows.Scan(&id, &name); err != nil {
+//  		// handle error
+//  	}
+//  }
+// Values of numeric variables are decoded without allocations.
+// Slice of bytes is copied into the variable reusing its capacity.
+func (r *Rows) Scan(dest ...interface{}) error {
+	if left := len(r.resultSet.Columns) - r.readColumns; len(dest) > left {
+		return fmt.Errorf("mysqldriver: can't scan %d values, %d columns left in the row", len(dest), left)
+	}
+
+	for i, d := range dest {
+		typ := reflect.TypeOf(d)
+		if typ == nil || typ.Kind() != reflect.Ptr || scanKindOf(typ.Elem()) == scanUnsupported {
+			return fmt.Errorf("mysqldriver: unsupported type %T of Scan destination %d", d, i)
+		}
+		ptr := unsafe.Pointer(reflect.ValueOf(d).Pointer())
+		if ptr == nil {
+			return fmt.Errorf("mysqldriver: nil Scan destination %d", i)
+		}
+		if err := r.scanValue(scanKindOf(typ.Elem()), ptr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanStruct reads the row into the struct pointed by dest. Columns are
+// matched with fields by the name given in `mysql:"name"` tag or by the name
+// of the field ignoring the case. Fields tagged with `mysql:"-"` and columns
+// without a matching field are skipped. Fields of embedded structs
+// are matched as if they were fields of dest.
+//  type Dog struct {
+//  	ID    int64  `mysql:"id"`
+//  	Name  string `mysql:"name"`
+//  	Owner string `mysql:"owner_name"`
+//  }
+//
+//  rows, _ := conn.Query("SELECT id, name, owner_name FROM dogs")
+//  for rows.Next() {
+//  	var dog Dog
+//  	if err := rows.ScanStruct(&dog); err != nil {
+//  		// handle error
+//  	}
+//  }
+// Mapping of the columns is computed once for the type of the struct and
+// columns of the result set, so values are decoded the same way as by Scan.
+func (r *Rows) ScanStruct(dest interface{}) error {
+	if r.readColumns > 0 {
+		return errScanStructRead
+	}
+
+	typ := reflect.TypeOf(dest)
+	if r.plan == nil || r.plan.typ != typ {
+		plan, err := r.scanPlan(typ)
+		if err != nil {
+			return err
+		}
+		r.plan = plan
+	}
+
+	base := unsafe.Pointer(reflect.ValueOf(dest).Pointer())
+	if base == nil {
+		return errors.New("mysqldriver: nil ScanStruct destination")
+	}
+	for _, field := range r.plan.fields {
+		if err := r.scanValue(field.kind, unsafe.Pointer(uintptr(base)+field.offset)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPlan returns the cached plan for the type
+// and the columns of the result set or builds a new one
+func (r *Rows) scanPlan(typ reflect.Type) (*scanPlan, error) {
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mysqldriver: ScanStruct destination must be a pointer to struct, got %v", typ)
+	}
+
+	names := make([]string, len(r.resultSet.Columns))
+	for i, column := range r.resultSet.Columns {
+		names[i] = column.Name
+	}
+	key := scanPlanKey{typ: typ, columns: strings.Join(names, "\x00")}
+	if plan, ok := scanPlans.Load(key); ok {
+		return plan.(*scanPlan), nil
+	}
+
+	fields := make(map[string]reflect.StructField)
+	structFields(typ.Elem(), 0, fields)
+	plan := &scanPlan{typ: typ, fields: make([]scanField, len(names))}
+	for i, name := range names {
+		field, ok := fields[strings.ToLower(name)]
+		if !ok {
+			plan.fields[i] = scanField{kind: scanSkip}
+			continue
+		}
+		kind := scanKindOf(field.Type)
+		if kind == scanUnsupported {
+			return nil, fmt.Errorf("mysqldriver: unsupported type %v of field %s for column %q", field.Type, field.Name, name)
+		}
+		plan.fields[i] = scanField{offset: field.Offset, kind: kind}
+	}
+
+	scanPlans.Store(key, plan)
+	return plan, nil
+}
+
+// structFields collects exported fields of the struct by the lower-cased
+// name of the column. Offsets of the fields are relative to the outermost
+// struct. Fields of the struct take precedence over fields of embedded ones.
+func structFields(typ reflect.Type, offset uintptr, fields map[string]reflect.StructField) {
+	embedded := make(map[string]reflect.StructField)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("mysql")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			structFields(field.Type, offset+field.Offset, embedded)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := strings.ToLower(tag)
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		field.Offset += offset
+		fields[name] = field
+	}
+
+	for name, field := range embedded {
+		if _, ok := fields[name]; !ok {
+			fields[name] = field
+		}
+	}
+}
+
+func scanKindOf(typ reflect.Type) scanKind {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return scanBool
+	case reflect.Int:
+		return scanInt
+	case reflect.Int8:
+		return scanInt8
+	case reflect.Int16:
+		return scanInt16
+	case reflect.Int32:
+		return scanInt32
+	case reflect.Int64:
+		return scanInt64
+	case reflect.Uint:
+		return scanUint
+	case reflect.Uint8:
+		return scanUint8
+	case reflect.Uint16:
+		return scanUint16
+	case reflect.Uint32:
+		return scanUint32
+	case reflect.Uint64:
+		return scanUint64
+	case reflect.Float32:
+		return scanFloat32
+	case reflect.Float64:
+		return scanFloat64
+	case reflect.String:
+		return scanString
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return scanBytes
+		}
+	}
+	return scanUnsupported
+}
+
+// scanValue reads the next value of the row
+// into the variable of the kind pointed by ptr
+func (r *Rows) scanValue(kind scanKind, ptr unsafe.Pointer) error {
+	value := r.nextValue()
+	switch kind {
+	case scanSkip:
+		return nil
+	case scanString:
+		*(*string)(ptr) = r.string(value)
+		return nil
+	case scanBytes:
+		if value.null {
+			*(*[]byte)(ptr) = nil
+		} else {
+			*(*[]byte)(ptr) = append((*(*[]byte)(ptr))[:0], r.bytes(value)...)
+		}
+		return nil
+	}
+
+	var err error
+	switch kind {
+	case scanBool:
+		var b bool
+		if !value.null {
+			b, err = r.parseBool(value)
+		}
+		*(*bool)(ptr) = b
+	case scanInt, scanInt8, scanInt16, scanInt32, scanInt64:
+		var num int64
+		if !value.null {
+			num, err = r.parseInt(value, kind.bitSize())
+		}
+		storeInt(kind, ptr, num)
+	case scanUint, scanUint8, scanUint16, scanUint32, scanUint64:
+		var num uint64
+		if !value.null {
+			num, err = r.parseUint(value, kind.bitSize())
+		}
+		storeUint(kind, ptr, num)
+	case scanFloat32:
+		var num float64
+		if !value.null {
+			num, err = r.parseFloat(value, 32)
+		}
+		*(*float32)(ptr) = float32(num)
+	case scanFloat64:
+		var num float64
+		if !value.null {
+			num, err = r.parseFloat(value, 64)
+		}
+		*(*float64)(ptr) = num
+	}
+	return err
+}
+
+func (k scanKind) bitSize() int {
+	switch k {
+	case scanInt8, scanUint8:
+		return 8
+	case scanInt16, scanUint16:
+		return 16
+	case scanInt32, scanUint32:
+		return 32
+	case scanInt64, scanUint64:
+		return 64
+	}
+	return strconv.IntSize
+}
+
+func storeInt(kind scanKind, ptr unsafe.Pointer, num int64) {
+	switch kind {
+	case scanInt:
+		*(*int)(ptr) = int(num)
+	case scanInt8:
+		*(*int8)(ptr) = int8(num)
+	case scanInt16:
+		*(*int16)(ptr) = int16(num)
+	case scanInt32:
+		*(*int32)(ptr) = int32(num)
+	case scanInt64:
+		*(*int64)(ptr) = num
+	}
+}
+
+func storeUint(kind scanKind, ptr unsafe.Pointer, num uint64) {
+	switch kind {
+	case scanUint:
+		*(*uint)(ptr) = uint(num)
+	case scanUint8:
+		*(*uint8)(ptr) = uint8(num)
+	case scanUint16:
+		*(*uint16)(ptr) = uint16(num)
+	case scanUint32:
+		*(*uint32)(ptr) = uint32(num)
+	case scanUint64:
+		*(*uint64)(ptr) = num
+	}
+}
+
+func (r *Rows) parseUint(value columnValue, bitSize int) (uint64, error) {
+	if value.column != nil {
+		if num, unsigned, ok := binaryInt(value.data, value.column); ok {
+			if !unsigned && int64(num) < 0 {
+				return 0, rangeError("ParseUint", strconv.FormatInt(int64(num), 10))
+			}
+			if max := uint64(1)<<uint(bitSize) - 1; bitSize < 64 && num > max {
+				return max, rangeError("ParseUint", strconv.FormatUint(num, 10))
+			}
+			return num, nil
+		}
+	}
+	return strconv.ParseUint(string(r.bytes(value)), 10, bitSize)
+}
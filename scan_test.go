@@ -0,0 +1,129 @@
+package mysqldriver
+
+import (
+	"testing"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// textRows returns rows positioned at the row of the text protocol
+func textRows(names []string, values ...string) *Rows {
+	rows := &Rows{columns: make(map[string]columnValue)}
+	for _, name := range names {
+		rows.resultSet.Columns = append(rows.resultSet.Columns, mysqlproto.Column{Name: name})
+	}
+	for _, value := range values {
+		if value == "NULL" {
+			rows.packet = append(rows.packet, 0xfb)
+			continue
+		}
+		rows.packet = append(rows.packet, byte(len(value)))
+		rows.packet = append(rows.packet, value...)
+	}
+	return rows
+}
+
+type dogName string
+
+func TestRowsScan(t *testing.T) {
+	rows := textRows([]string{"id", "name", "age", "score", "good", "note", "owner"},
+		"-7", "Rex", "200", "3.5", "1", "NULL", "bob")
+
+	var (
+		id    int64
+		name  dogName
+		age   uint8
+		score float32
+		good  bool
+		note  = []byte("old")
+	)
+	assert.NoError(t, rows.Scan(&id, &name, &age, &score, &good, &note))
+	assert.Equal(t, id, int64(-7))
+	assert.Equal(t, name, dogName("Rex"))
+	assert.Equal(t, age, uint8(200))
+	assert.Equal(t, score, float32(3.5))
+	assert.True(t, good)
+	assert.Nil(t, note)
+
+	var owner, extra string
+	assert.EqualError(t, rows.Scan(&owner, &extra), "mysqldriver: can't scan 2 values, 1 columns left in the row")
+	assert.EqualError(t, rows.Scan(owner), "mysqldriver: unsupported type string of Scan destination 0")
+	assert.NoError(t, rows.Scan(&owner))
+	assert.Equal(t, owner, "bob")
+}
+
+func TestRowsScanError(t *testing.T) {
+	rows := textRows([]string{"id"}, "-1")
+	var id uint
+	assert.EqualError(t, rows.Scan(&id), `strconv.ParseUint: parsing "-1": invalid syntax`)
+}
+
+type dogBase struct {
+	ID   int
+	Name string `mysql:"name"`
+}
+
+type dog struct {
+	dogBase
+	Name    string `mysql:"nickname"`
+	Owner   []byte `mysql:"owner_name"`
+	Age     int8
+	Ignored int `mysql:"-"`
+	secret  int
+}
+
+func TestRowsScanStruct(t *testing.T) {
+	columns := []string{"id", "name", "nickname", "owner_name", "AGE", "ignored", "secret", "unknown"}
+	rows := textRows(columns, "1", "Rex", "Rexy", "bob", "NULL", "5", "6", "7")
+
+	var d dog
+	assert.NoError(t, rows.ScanStruct(&d))
+	assert.Equal(t, d, dog{
+		dogBase: dogBase{ID: 1, Name: "Rex"},
+		Name:    "Rexy",
+		Owner:   []byte("bob"),
+	})
+	assert.Equal(t, rows.ScanStruct(&d), errScanStructRead)
+
+	// plan is cached by the type and columns
+	plan := rows.plan
+	rows = textRows(columns, "2", "Max", "Maxy", "ann", "3", "5", "6", "7")
+	assert.NoError(t, rows.ScanStruct(&d))
+	assert.True(t, rows.plan == plan)
+	assert.Equal(t, d.ID, 2)
+	assert.Equal(t, d.Age, int8(3))
+}
+
+func TestRowsScanStructErrors(t *testing.T) {
+	rows := textRows([]string{"id"}, "1")
+	var id int
+	assert.EqualError(t, rows.ScanStruct(&id), "mysqldriver: ScanStruct destination must be a pointer to struct, got *int")
+
+	var v struct {
+		ID []int `mysql:"id"`
+	}
+	assert.EqualError(t, rows.ScanStruct(&v), `mysqldriver: unsupported type []int of field ID for column "id"`)
+}
+
+func TestRowsScanStructDoesntAllocate(t *testing.T) {
+	var v struct {
+		ID    int64   `mysql:"id"`
+		Age   uint16  `mysql:"age"`
+		Score float64 `mysql:"score"`
+		Good  bool    `mysql:"good"`
+	}
+	rows := textRows([]string{"id", "age", "score", "good"}, "123456789", "7", "1.25", "0")
+	packet := rows.packet
+	assert.NoError(t, rows.ScanStruct(&v))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		rows.offset = 0
+		rows.readColumns = 0
+		rows.packet = packet
+		rows.ScanStruct(&v)
+	})
+	assert.Equal(t, allocs, float64(0))
+	assert.Equal(t, v.ID, int64(123456789))
+	assert.Equal(t, v.Score, 1.25)
+}
@@ -0,0 +1,109 @@
+package mysqldriver
+
+import (
+	"strings"
+)
+
+// Logger logs slow queries of DB (see DB.SlowQueryLogger).
+// It's satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// logSlowQuery logs the query with literals replaced by "?"
+func (db *DB) logSlowQuery(query *QueryInfo) {
+	sql := NormalizeSQL(query.SQL)
+	if query.Err != nil {
+		db.SlowQueryLogger.Printf("mysqldriver: slow query: %v, %d rows, %d syscalls, connection %d, error %q: %s",
+			query.Duration, query.Rows, query.Stats.Syscalls, query.ConnectionID, query.Err.Error(), sql)
+		return
+	}
+	db.SlowQueryLogger.Printf("mysqldriver: slow query: %v, %d rows, %d syscalls, connection %d: %s",
+		query.Duration, query.Rows, query.Stats.Syscalls, query.ConnectionID, sql)
+}
+
+// NormalizeSQL replaces string and numeric literals of the query with "?",
+// removes comments and collapses whitespaces, so queries which differ only
+// in values are the same.
+//  NormalizeSQL("SELECT * FROM dogs WHERE id = 1 AND name = 'Rex'")
+//  // SELECT * FROM dogs WHERE id = ? AND name = ?
+func NormalizeSQL(sql string) string {
+	buf := make([]byte, 0, len(sql))
+	space := false // whitespace is pending
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		end := i
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			space = true
+			continue
+		case ch == '\'' || ch == '"':
+			end = skipQuoted(sql, i, false)
+		case (ch == 'x' || ch == 'X' || ch == 'b' || ch == 'B' || ch == 'n' || ch == 'N') &&
+			i+1 < len(sql) && sql[i+1] == '\'' && !inIdentifier(sql, i):
+			end = skipQuoted(sql, i+1, false) // hex, bit or national string
+		case ch >= '0' && ch <= '9' && !inIdentifier(sql, i):
+			end = i + 1
+			for end < len(sql) && (isIdentifierChar(sql[end]) || sql[end] == '.') {
+				end++
+			}
+		case ch == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9' && !inIdentifier(sql, i):
+			end = i + 1
+			for end < len(sql) && isIdentifierChar(sql[end]) {
+				end++
+			}
+		case ch == '`':
+			end = skipQuoted(sql, i, true)
+			buf = appendSpace(buf, space)
+			buf = append(buf, sql[i:end]...)
+			space = false
+			i = end - 1
+			continue
+		case ch == '#' || (ch == '-' && i+2 < len(sql) && sql[i+1] == '-' && (sql[i+2] == ' ' || sql[i+2] == '\t')):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			space = true
+			continue
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			if close := strings.Index(sql[i+2:], "*/"); close >= 0 {
+				i += close + 3
+			} else {
+				i = len(sql)
+			}
+			space = true
+			continue
+		default:
+			buf = appendSpace(buf, space)
+			buf = append(buf, ch)
+			space = false
+			continue
+		}
+
+		// literal
+		buf = appendSpace(buf, space)
+		buf = append(buf, '?')
+		space = false
+		i = end - 1
+	}
+	return string(buf)
+}
+
+// appendSpace appends pending whitespace unless it's
+// at the beginning of the query
+func appendSpace(buf []byte, space bool) []byte {
+	if space && len(buf) > 0 {
+		return append(buf, ' ')
+	}
+	return buf
+}
+
+// inIdentifier reports whether the character at the position i
+// continues an identifier, e.g. digit of "t1" or prefix of "tax'"
+func inIdentifier(sql string, i int) bool {
+	return i > 0 && isIdentifierChar(sql[i-1])
+}
+
+func isIdentifierChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '$'
+}
@@ -0,0 +1,90 @@
+package mysqldriver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM dogs WHERE id = 1 AND name = 'Rex'":          "SELECT * FROM dogs WHERE id = ? AND name = ?",
+		"SELECT  a,\n\tb FROM t1 WHERE c IN (1, 2.5, -3e10)":        "SELECT a, b FROM t1 WHERE c IN (?, ?, -?)",
+		`INSERT INTO t VALUES ("it''s", 'a\'b', X'0F', b'101', .5)`: "INSERT INTO t VALUES (?, ?, ?, ?, ?)",
+		"SELECT `col 1`, `x'1` FROM `t2` WHERE x = 0x1F":            "SELECT `col 1`, `x'1` FROM `t2` WHERE x = ?",
+		"SELECT 1 /* comment 2 */ # another 3\n-- last 4\nFROM t":   "SELECT ? FROM t",
+		"  DO ?  ":   "DO ?",
+		"SELECT tax": "SELECT tax",
+	}
+	for sql, normalized := range cases {
+		assert.Equal(t, NormalizeSQL(sql), normalized)
+	}
+}
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test", 1, time.Duration(0))
+	defer db.Close()
+	logger := &recordingLogger{}
+	db.SlowQueryLogger = logger
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	_, err = conn.Exec("DO SLEEP(1)")
+	assert.NoError(t, err)
+	assert.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], "mysqldriver: slow query: ")
+	assert.Contains(t, logger.lines[0], "0 rows, 1 syscalls, connection 42: DO SLEEP(?)")
+
+	db.SlowQueryThreshold = time.Hour
+	_, err = conn.Exec("DO 1")
+	assert.NoError(t, err)
+	assert.Len(t, logger.lines, 1)
+}
+
+func TestSlowQueryLogError(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test", 1, time.Duration(0))
+	defer db.Close()
+	logger := &recordingLogger{}
+	db.SlowQueryLogger = logger
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	_, err = conn.ExecArgs("DO ?, ?", 1)
+	assert.Error(t, err)
+	assert.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], `error "mysqldriver: query has more placeholders than 1 arguments": DO ?, ?`)
+}
+
+func TestSlowQueryLogRows(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 1, time.Duration(0))
+	defer db.Close()
+	logger := &recordingLogger{}
+	db.SlowQueryLogger = logger
+	db.SlowQueryThreshold = 50 * time.Millisecond
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	rows, err := conn.Query("SELECT 1 UNION SELECT 2")
+	assert.NoError(t, err)
+	rows.Next()
+	time.Sleep(60 * time.Millisecond) // draining rows counts as the query time
+	for rows.Next() {
+	}
+	assert.Len(t, logger.lines, 1)
+	assert.Contains(t, logger.lines[0], "2 rows")
+	assert.Contains(t, logger.lines[0], ": SELECT ? UNION SELECT ?")
+}
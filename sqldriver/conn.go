@@ -0,0 +1,233 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pubnative/mysqldriver-go"
+	"github.com/pubnative/mysqlproto-go"
+)
+
+var errNamedArgs = errors.New("mysqldriver: named arguments aren't supported")
+
+var isolationLevels = map[sql.IsolationLevel]mysqldriver.IsolationLevel{
+	sql.LevelDefault:         mysqldriver.IsolationDefault,
+	sql.LevelReadUncommitted: mysqldriver.IsolationReadUncommitted,
+	sql.LevelReadCommitted:   mysqldriver.IsolationReadCommitted,
+	sql.LevelRepeatableRead:  mysqldriver.IsolationRepeatableRead,
+	sql.LevelSerializable:    mysqldriver.IsolationSerializable,
+}
+
+// conn adapts mysqldriver.Conn to database/sql
+type conn struct {
+	conn *mysqldriver.Conn
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, err := c.conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{stmt: s}, nil
+}
+
+func (c *conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts the transaction. *mysqldriver.Tx implements driver.Tx,
+// so it's returned as is.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	level, ok := isolationLevels[sql.IsolationLevel(opts.Isolation)]
+	if !ok {
+		return nil, fmt.Errorf("mysqldriver: unsupported isolation level %v", sql.IsolationLevel(opts.Isolation))
+	}
+	if !c.conn.Valid() {
+		return nil, driver.ErrBadConn
+	}
+	tx, err := c.conn.BeginContext(ctx, mysqldriver.TxOptions{Isolation: level, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		// nil *mysqldriver.Tx isn't nil driver.Tx
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.conn.QueryArgsContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(r), nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	pkt, err := c.conn.ExecArgsContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	return result(pkt), nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if !c.conn.Valid() {
+		return driver.ErrBadConn
+	}
+	return c.conn.PingContext(ctx)
+}
+
+// CheckNamedValue passes arguments supported by mysqldriver as is,
+// other ones are converted by database/sql
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case nil, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, bool, string, []byte, time.Time:
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession prevents reuse of the broken connection
+func (c *conn) ResetSession(ctx context.Context) error {
+	if !c.conn.Valid() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	return c.conn.Valid()
+}
+
+// stmt adapts mysqldriver.Stmt to database/sql
+type stmt struct {
+	stmt *mysqldriver.Stmt
+}
+
+var (
+	_ driver.StmtQueryContext = (*stmt)(nil)
+	_ driver.StmtExecContext  = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	pkt, err := s.stmt.Exec(values(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return result(pkt), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	r, err := s.stmt.Query(values(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(r), nil
+}
+
+// ExecContext executes the statement. Prepared statements aren't
+// bound to the context, so it's checked only before the execution.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	pkt, err := s.stmt.Exec(values...)
+	if err != nil {
+		return nil, err
+	}
+	return result(pkt), nil
+}
+
+// QueryContext executes the statement (see func (*stmt) ExecContext)
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r, err := s.stmt.Query(values...)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(r), nil
+}
+
+// result implements driver.Result
+type result mysqlproto.OKPacket
+
+func (r result) LastInsertId() (int64, error) {
+	return int64(r.LastInsertID), nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return int64(r.AffectedRows), nil
+}
+
+// namedValues converts positional arguments of database/sql
+func namedValues(args []driver.NamedValue) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedArgs
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+func values(args []driver.Value) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return values
+}
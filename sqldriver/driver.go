@@ -0,0 +1,88 @@
+// Package sqldriver exposes mysqldriver through database/sql, so the same
+// driver and data source format are used by code which requires
+// the generic interface. Importing the package registers the driver
+// under "mysqldriver" name.
+//  import (
+//  	"database/sql"
+//
+//  	_ "github.com/pubnative/mysqldriver-go/sqldriver"
+//  )
+//
+//  db, err := sql.Open("mysqldriver", "root@tcp(127.0.0.1:3306)/test?timeout=5s")
+//
+// Pool of database/sql is used instead of mysqldriver.DB, so pool
+// parameters of the data source are ignored. Queries with arguments
+// are interpolated on the client side (see func (*mysqldriver.Conn) QueryArgs),
+// prepared statements are executed on the server.
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/pubnative/mysqldriver-go"
+)
+
+// DriverName is the name the driver is registered with in database/sql
+const DriverName = "mysqldriver"
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver implements driver.Driver and driver.DriverContext
+type Driver struct{}
+
+// Open establishes a new connection using the data source
+// (see func mysqldriver.ParseDSN)
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+// OpenConnector parses the data source once, so it isn't
+// parsed by every new connection
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{cfg: cfg, driver: d}, nil
+}
+
+// Connector establishes connections with the given config.
+// It can be used with sql.OpenDB when the config is built in the code.
+//  cfg, _ := mysqldriver.ParseDSN("root@tcp(127.0.0.1:3306)/test")
+//  cfg.TLSConfig = tlsConfig
+//  db := sql.OpenDB(sqldriver.NewConnector(cfg))
+type Connector struct {
+	cfg    *mysqldriver.Config
+	driver *Driver
+}
+
+// NewConnector returns connector using the config
+func NewConnector(cfg *mysqldriver.Config) *Connector {
+	return &Connector{cfg: cfg, driver: &Driver{}}
+}
+
+// Connect establishes a new connection bound to the context
+// (see func mysqldriver.NewConnFromConfig)
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	mc, err := mysqldriver.NewConnFromConfig(ctx, c.cfg)
+	if err != nil {
+		if mc != nil {
+			mc.Close()
+		}
+		return nil, err
+	}
+	return &conn{conn: mc}, nil
+}
+
+// Driver returns the driver of the connector
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
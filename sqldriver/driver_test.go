@@ -0,0 +1,111 @@
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqldriver-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const dataSource = "root@tcp(127.0.0.1:3306)/test"
+
+func TestOpenConnectorInvalidDSN(t *testing.T) {
+	_, err := (&Driver{}).OpenConnector("root@tcp(127.0.0.1:3306)/test?pool=x")
+	assert.EqualError(t, err, `mysqldriver: invalid DSN parameter pool="x"`)
+}
+
+func TestCheckNamedValue(t *testing.T) {
+	c := &conn{}
+	for _, value := range []interface{}{nil, 1, int8(1), uint64(1 << 63), float32(1), true, "a", []byte("a"), time.Now()} {
+		assert.NoError(t, c.CheckNamedValue(&driver.NamedValue{Value: value}))
+	}
+	assert.Equal(t, c.CheckNamedValue(&driver.NamedValue{Value: sql.NullInt64{}}), driver.ErrSkip)
+}
+
+func TestNamedValues(t *testing.T) {
+	values, err := namedValues([]driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: "a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, values, []interface{}{int64(1), "a"})
+
+	_, err = namedValues([]driver.NamedValue{{Name: "id", Ordinal: 1, Value: int64(1)}})
+	assert.Equal(t, err, errNamedArgs)
+}
+
+func TestBeginTxUnsupportedIsolation(t *testing.T) {
+	c := &conn{}
+	_, err := c.BeginTx(context.Background(), driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSnapshot)})
+	assert.EqualError(t, err, "mysqldriver: unsupported isolation level Snapshot")
+}
+
+func TestBeginTxBadConn(t *testing.T) {
+	c := &conn{conn: &mysqldriver.Conn{}} // never established
+	tx, err := c.BeginTx(context.Background(), driver.TxOptions{})
+	assert.Equal(t, err, driver.ErrBadConn)
+	assert.True(t, tx == nil)
+}
+
+func TestSQLQuery(t *testing.T) {
+	db, err := sql.Open(DriverName, dataSource)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var (
+		id    int
+		name  string
+		score float64
+		note  sql.NullString
+	)
+	err = db.QueryRow("SELECT ?, ?, 1.5, NULL", 5, "Rex").Scan(&id, &name, &score, &note)
+	assert.NoError(t, err)
+	assert.Equal(t, id, 5)
+	assert.Equal(t, name, "Rex")
+	assert.Equal(t, score, 1.5)
+	assert.False(t, note.Valid)
+
+	var value interface{}
+	assert.NoError(t, db.QueryRow("SELECT 42").Scan(&value))
+	assert.Equal(t, value, int64(42))
+}
+
+func TestSQLExecTx(t *testing.T) {
+	db, err := sql.Open(DriverName, dataSource)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx) // temporary table belongs to the connection
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "CREATE TEMPORARY TABLE sqldriver_tx (id INT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(10))")
+	assert.NoError(t, err)
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	assert.NoError(t, err)
+	res, err := tx.Exec("INSERT INTO sqldriver_tx (name) VALUES (?), (?)", "Rex", "Max")
+	assert.NoError(t, err)
+	affected, _ := res.RowsAffected()
+	assert.Equal(t, affected, int64(2))
+	assert.NoError(t, tx.Rollback())
+
+	var count int
+	assert.NoError(t, conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqldriver_tx").Scan(&count))
+	assert.Equal(t, count, 0)
+}
+
+func TestSQLPrepare(t *testing.T) {
+	db, err := sql.Open(DriverName, dataSource)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	stmt, err := db.Prepare("SELECT ? + 1")
+	assert.NoError(t, err)
+	defer stmt.Close()
+
+	var num int64
+	assert.NoError(t, stmt.QueryRow(int64(41)).Scan(&num))
+	assert.Equal(t, num, int64(42))
+}
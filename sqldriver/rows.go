@@ -0,0 +1,88 @@
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"io"
+
+	"github.com/pubnative/mysqldriver-go"
+)
+
+// rows adapts mysqldriver.Rows to database/sql
+type rows struct {
+	rows    *mysqldriver.Rows
+	columns []mysqldriver.ColumnInfo
+	names   []string
+}
+
+var _ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+
+func newRows(r *mysqldriver.Rows) *rows {
+	columns := r.Columns()
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return &rows{rows: r, columns: columns, names: names}
+}
+
+func (r *rows) Columns() []string {
+	return r.names
+}
+
+// Close reads the rest of the rows, so the connection can be reused
+func (r *rows) Close() error {
+	return r.rows.Close()
+}
+
+// Next reads the row into dest. Integer and float columns are decoded
+// into int64 and float64, values of other columns are returned as bytes
+// valid until the next row is read. Unsigned BIGINT is returned as bytes
+// as well because it may not fit into int64.
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.rows.Next() {
+		if err := r.rows.LastError(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	for i, column := range r.columns {
+		dest[i] = r.value(column)
+	}
+	return r.rows.LastError()
+}
+
+func (r *rows) value(column mysqldriver.ColumnInfo) driver.Value {
+	switch column.Type {
+	case mysqldriver.TypeTiny, mysqldriver.TypeShort, mysqldriver.TypeInt24,
+		mysqldriver.TypeLong, mysqldriver.TypeYear:
+		if num, null := r.rows.NullInt64(); !null {
+			return num
+		}
+		return nil
+	case mysqldriver.TypeLongLong:
+		if column.Unsigned() {
+			break
+		}
+		if num, null := r.rows.NullInt64(); !null {
+			return num
+		}
+		return nil
+	case mysqldriver.TypeFloat, mysqldriver.TypeDouble:
+		if num, null := r.rows.NullFloat64(); !null {
+			return num
+		}
+		return nil
+	}
+
+	if b, null := r.rows.NullBytes(); !null {
+		return b
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName returns the type of the column
+// as it's called in MySQL protocol, e.g. "VAR_STRING"
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.columns[index].Type.String()
+}
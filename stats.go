@@ -0,0 +1,119 @@
+package mysqldriver
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+func (c *Conn) resetStats() {
+	c.conn.ResetStats()
+	c.meter.read = 0
+	c.meter.written = 0
+}
+
+// meter counts bytes transferred over the connection
+// including TLS records
+type meter struct {
+	net.Conn
+	read    uint64
+	written uint64
+}
+
+func (m *meter) Read(b []byte) (int, error) {
+	n, err := m.Conn.Read(b)
+	m.read += uint64(n)
+	return n, err
+}
+
+func (m *meter) Write(b []byte) (int, error) {
+	n, err := m.Conn.Write(b)
+	m.written += uint64(n)
+	return n, err
+}
+
+// DBStats contains statistics of the pool
+type DBStats struct {
+	OpenConnections int // number of established connections, both idle and in use
+	Idle            int // number of connections in the pool
+	InUse           int // number of connections taken from the pool
+
+	WaitCount    int64         // number of GetConn calls which waited for a connection (see Config.MaxOpen)
+	WaitDuration time.Duration // total time spent waiting for connections
+
+	Dials      int64 // number of attempts to establish a connection, including ones sending KILL QUERY
+	DialErrors int64 // number of failed attempts to establish a connection
+
+	MaxIdleTimeClosed int64 // number of connections closed because of Config.MaxIdleTime
+	MaxLifetimeClosed int64 // number of connections closed because of Config.MaxLifetime
+	InvalidClosed     int64 // number of broken connections discarded by the pool
+
+	// Cumulative statistics of the connections collected
+	// when they're returned to the pool or closed by it
+	Conn Stats
+}
+
+// dbStats holds counters of the pool
+type dbStats struct {
+	sync.Mutex
+	open              int
+	waitCount         int64
+	waitDuration      time.Duration
+	dials             int64
+	dialErrors        int64
+	maxIdleTimeClosed int64
+	maxLifetimeClosed int64
+	invalidClosed     int64
+	conn              Stats
+}
+
+// Stats returns statistics of the pool
+func (db *DB) Stats() DBStats {
+	idle := len(db.conns)
+
+	db.stats.Lock()
+	defer db.stats.Unlock()
+	stats := DBStats{
+		OpenConnections:   db.stats.open,
+		Idle:              idle,
+		InUse:             db.stats.open - idle,
+		WaitCount:         db.stats.waitCount,
+		WaitDuration:      db.stats.waitDuration,
+		Dials:             db.stats.dials,
+		DialErrors:        db.stats.dialErrors,
+		MaxIdleTimeClosed: db.stats.maxIdleTimeClosed,
+		MaxLifetimeClosed: db.stats.maxLifetimeClosed,
+		InvalidClosed:     db.stats.invalidClosed,
+		Conn:              db.stats.conn,
+	}
+	if stats.InUse < 0 {
+		stats.InUse = 0 // connection is being returned concurrently
+	}
+	return stats
+}
+
+// inc increments the counter of the pool
+func (s *dbStats) inc(counter *int64) {
+	s.Lock()
+	*counter++
+	s.Unlock()
+}
+
+func (s *dbStats) addOpen(delta int) {
+	s.Lock()
+	s.open += delta
+	s.Unlock()
+}
+
+func (s *dbStats) addWait(d time.Duration) {
+	s.Lock()
+	s.waitCount++
+	s.waitDuration += d
+	s.Unlock()
+}
+
+func (s *dbStats) addConn(stats Stats) {
+	s.Lock()
+	s.conn = s.conn.Add(stats)
+	s.Unlock()
+}
@@ -0,0 +1,115 @@
+package mysqldriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnStatsCountsBytes(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+
+	conn, err := NewConn("root", "", "tcp", server.addr(), "test", time.Duration(0))
+	assert.NoError(t, err)
+	before := conn.Stats()
+	assert.True(t, before.BytesRead > 0)
+	assert.True(t, before.BytesWritten > 0)
+
+	assert.NoError(t, conn.Ping())
+	after := conn.Stats()
+	assert.Equal(t, after.BytesWritten-before.BytesWritten, uint64(5))
+	assert.Equal(t, after.BytesRead-before.BytesRead, uint64(11))
+
+	conn.resetStats()
+	assert.Equal(t, conn.Stats(), Stats{})
+}
+
+func TestStatsAdd(t *testing.T) {
+	stats := Stats{Syscalls: 1, BytesRead: 2, BytesWritten: 3}.Add(Stats{Syscalls: 4, BytesRead: 5, BytesWritten: 6})
+	assert.Equal(t, stats, Stats{Syscalls: 5, BytesRead: 7, BytesWritten: 9})
+}
+
+func TestDBStatsConnections(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test?maxOpen=2", 2, 0)
+
+	conn1, err := db.GetConn()
+	assert.NoError(t, err)
+	conn2, err := db.GetConn()
+	assert.NoError(t, err)
+	stats := db.Stats()
+	assert.Equal(t, stats.OpenConnections, 2)
+	assert.Equal(t, stats.InUse, 2)
+	assert.Equal(t, stats.Idle, 0)
+	assert.Equal(t, stats.Dials, int64(2))
+
+	assert.NoError(t, conn1.Ping())
+	assert.NoError(t, db.PutConn(conn1))
+	stats = db.Stats()
+	assert.Equal(t, stats.OpenConnections, 2)
+	assert.Equal(t, stats.InUse, 1)
+	assert.Equal(t, stats.Idle, 1)
+	assert.True(t, stats.Conn.BytesRead > 0)
+	assert.True(t, stats.Conn.BytesWritten > 0)
+	assert.Equal(t, conn1.Stats(), Stats{})
+
+	conn2.valid = false
+	assert.NoError(t, db.PutConn(conn2))
+	stats = db.Stats()
+	assert.Equal(t, stats.OpenConnections, 1)
+	assert.Equal(t, stats.InUse, 0)
+	assert.Equal(t, stats.InvalidClosed, int64(1))
+
+	assert.Nil(t, db.Close())
+	assert.Equal(t, db.Stats().OpenConnections, 0)
+}
+
+func TestDBStatsWait(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test?maxOpen=1", 1, 0)
+	defer db.Close()
+
+	_, err := db.GetConn()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = db.GetConnContext(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	stats := db.Stats()
+	assert.Equal(t, stats.WaitCount, int64(1))
+	assert.True(t, stats.WaitDuration >= 20*time.Millisecond)
+}
+
+func TestDBStatsDialErrors(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:1)/test", 1, 0)
+	_, err := db.GetConn()
+	assert.Error(t, err)
+
+	stats := db.Stats()
+	assert.Equal(t, stats.Dials, int64(1))
+	assert.Equal(t, stats.DialErrors, int64(1))
+	assert.Equal(t, stats.OpenConnections, 0)
+}
+
+func TestDBStatsExpiredConnections(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	defer server.close()
+	db := NewDB("root@tcp("+server.addr()+")/test?maxLifetime=20ms", 1, 0)
+
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, db.PutConn(conn))
+
+	assert.Nil(t, db.Close())
+	stats := db.Stats()
+	assert.Equal(t, stats.MaxLifetimeClosed, int64(1))
+	assert.Equal(t, stats.OpenConnections, 0)
+}
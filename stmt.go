@@ -0,0 +1,199 @@
+package mysqldriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// ErrClosedStmt is returned when the statement was closed
+// or its connection was closed, broken or discarded by the pool
+var ErrClosedStmt = errors.New("mysqldriver: statement is closed")
+
+// Stmt is a server-side prepared statement.
+// Statement belongs to the connection which prepared it
+// and can't be used once the connection is closed
+// or discarded by the pool (see func (*DB) PutConn).
+type Stmt struct {
+	conn    *Conn
+	sql     string // text of the statement reported to DB.QueryHook
+	id      uint32
+	params  int
+	columns int
+	closed  bool
+}
+
+// Prepare creates a prepared statement on the server.
+// Parameters of the query are marked with "?" placeholders.
+//  stmt, err := conn.Prepare("SELECT name FROM dogs WHERE id = ?")
+//  if err != nil {
+//  	// handle error
+//  }
+//  defer stmt.Close()
+//  rows, err := stmt.Query(1)
+func (c *Conn) Prepare(sql string) (*Stmt, error) {
+	stmt, err := c.prepare(sql)
+	return stmt, c.queryError(sql, err)
+}
+
+func (c *Conn) prepare(sql string) (*Stmt, error) {
+	c.buf = append(startPacket(c.buf, comStmtPrepare), sql...)
+	req, err := finishPacket(c.buf, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.conn.Write(req); err != nil {
+		c.valid = false
+		return nil, err
+	}
+
+	packet, err := c.conn.NextPacket()
+	if err != nil {
+		c.valid = false
+		return nil, err
+	}
+
+	if packet.Payload[0] != mysqlproto.OK_PACKET {
+		return nil, handleOK(packet.Payload, c.conn.CapabilityFlags)
+	}
+
+	// COM_STMT_PREPARE_OK: status, statement id, number of columns,
+	// number of params, reserved byte, warnings count
+	// (see https://dev.mysql.com/doc/internals/en/com-stmt-prepare-response.html)
+	if len(packet.Payload) < 12 {
+		c.valid = false
+		return nil, fmt.Errorf("mysqldriver: broken COM_STMT_PREPARE response. Payload: %x", packet.Payload)
+	}
+
+	stmt := &Stmt{
+		conn:    c,
+		sql:     sql,
+		id:      readUint32(packet.Payload[1:]),
+		columns: int(readUint16(packet.Payload[5:])),
+		params:  int(readUint16(packet.Payload[7:])),
+	}
+
+	// definitions of params and columns aren't needed
+	// because column definitions are sent again with every result set
+	for _, count := range []int{stmt.params, stmt.columns} {
+		if count == 0 {
+			continue
+		}
+		for i := 0; i < count+1; i++ { // definitions followed by EOF packet
+			if _, err := c.conn.NextPacket(); err != nil {
+				c.valid = false
+				return nil, err
+			}
+		}
+	}
+
+	return stmt, nil
+}
+
+// NumInput returns the number of placeholder parameters
+func (s *Stmt) NumInput() int {
+	return s.params
+}
+
+// Query executes prepared SELECT statement with the given arguments.
+// Supported types of arguments are nil, integers, floats, bool,
+// string, []byte and time.Time.
+// Result set is encoded with the binary protocol, but it's read
+// the same way as result set of func (*Conn) Query
+func (s *Stmt) Query(args ...interface{}) (*Rows, error) {
+	c := s.conn
+	trace := c.startQuery(context.Background(), s.sql, args)
+	if err := s.execute(args); err != nil {
+		return trace.attach(c, nil, c.queryError(s.sql, err))
+	}
+
+	columns, _, err := c.readResultSet()
+	if err != nil {
+		return trace.attach(c, nil, c.queryError(s.sql, err))
+	}
+
+	rows := &Rows{
+		conn:      c,
+		resultSet: mysqlproto.ResultSet{Columns: columns},
+		empty:     len(columns) == 0,
+		binary:    true,
+		transcode: c.transcodeLatin1,
+		columns:   make(map[string]columnValue, len(columns)),
+	}
+	return trace.attach(c, rows, nil)
+}
+
+// Exec executes prepared statement which expects to return OK_PACKET
+// including INSERT/UPDATE/DELETE queries with the given arguments.
+// For SELECT statements see func (*Stmt) Query
+func (s *Stmt) Exec(args ...interface{}) (mysqlproto.OKPacket, error) {
+	c := s.conn
+	trace := c.startQuery(context.Background(), s.sql, args)
+	if err := s.execute(args); err != nil {
+		err = c.queryError(s.sql, err)
+		trace.end(c, err)
+		return mysqlproto.OKPacket{}, err
+	}
+	pkt, err := c.readOK()
+	err = c.queryError(s.sql, err)
+	trace.endExec(c, pkt, err)
+	return pkt, err
+}
+
+// Close deallocates the statement on the server.
+// It's safe to close the statement of the closed connection.
+func (s *Stmt) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	c := s.conn
+	if c.closed || !c.valid {
+		return nil
+	}
+
+	c.buf = appendUint32(startPacket(c.buf, comStmtClose), s.id)
+	req, _ := finishPacket(c.buf, 0)
+
+	// server doesn't send response to COM_STMT_CLOSE
+	if _, err := c.conn.Write(req); err != nil {
+		c.valid = false
+		return err
+	}
+	return nil
+}
+
+func (s *Stmt) execute(args []interface{}) error {
+	c := s.conn
+	if s.closed || c.closed || !c.valid {
+		return ErrClosedStmt
+	}
+
+	if len(args) != s.params {
+		return fmt.Errorf("mysqldriver: statement expects %d arguments, got %d", s.params, len(args))
+	}
+
+	buf := appendUint32(startPacket(c.buf, comStmtExecute), s.id)
+	buf = append(buf, 0)       // CURSOR_TYPE_NO_CURSOR
+	buf = appendUint32(buf, 1) // iteration count
+	buf, err := appendBinaryParams(buf, args)
+	c.buf = buf
+	if err != nil {
+		return err
+	}
+
+	req, err := finishPacket(buf, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.conn.Write(req); err != nil {
+		c.valid = false
+		return err
+	}
+	return nil
+}
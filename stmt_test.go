@@ -0,0 +1,180 @@
+package mysqldriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStmtQuerySelectValues(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		_, err := conn.Exec(`
+			INSERT INTO people(firstname,lastname,cars,houses,cats,dogs,age,married,grade,score)
+			VALUES("bob","ben",2,8,16,32,64,1,4.5,3.7),("one","two",1,2,33,44,55,0,7.7,8.8)
+		`)
+		assert.NoError(t, err)
+
+		stmt, err := conn.Prepare("SELECT * FROM people WHERE firstname = ? AND age > ?")
+		assert.NoError(t, err)
+		assert.Equal(t, stmt.NumInput(), 2)
+
+		rows, err := stmt.Query("bob", 18)
+		assert.NoError(t, err)
+		assert.True(t, conn.valid)
+		assert.True(t, rows.Next())
+		assert.Equal(t, rows.Int(), 1)
+		assert.Equal(t, rows.String(), "bob")
+		assert.Equal(t, rows.Bytes(), []byte("ben"))
+		assert.Equal(t, rows.Int8(), int8(2))
+		assert.Equal(t, rows.Int16(), int16(8))
+		assert.Equal(t, rows.Int32(), int32(16))
+		assert.Equal(t, rows.Int64(), int64(32))
+		assert.Equal(t, rows.Int(), 64)
+		assert.Equal(t, rows.Bool(), true)
+		assert.Equal(t, rows.Float32(), float32(4.5))
+		assert.Equal(t, rows.Float64(), float64(3.7))
+		assert.NoError(t, rows.LastError())
+		assert.False(t, rows.Next())
+
+		// statement can be executed many times
+		rows, err = stmt.Query("one", 18)
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		row := rows.Row()
+		assert.Equal(t, row.Int("id"), 2)
+		assert.Equal(t, row.String("lastname"), "two")
+		assert.Equal(t, row.Int("cats"), 33)
+		assert.NoError(t, rows.LastError())
+		assert.False(t, rows.Next())
+
+		assert.NoError(t, stmt.Close())
+	})
+}
+
+func TestStmtQuerySelectNULLValues(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		_, err := conn.Exec(`INSERT INTO people(firstname) VALUES(NULL)`)
+		assert.NoError(t, err)
+
+		stmt, err := conn.Prepare("SELECT firstname, cars, score FROM people WHERE id = ?")
+		assert.NoError(t, err)
+		rows, err := stmt.Query(1)
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		name, null := rows.NullString()
+		assert.Equal(t, name, "")
+		assert.True(t, null)
+		cars, null := rows.NullInt8()
+		assert.Equal(t, cars, int8(0))
+		assert.True(t, null)
+		score, null := rows.NullFloat64()
+		assert.Equal(t, score, float64(0))
+		assert.True(t, null)
+		assert.False(t, rows.Next())
+	})
+}
+
+func TestStmtExecInsertSuccess(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		stmt, err := conn.Prepare(`INSERT INTO people(firstname,cars,married,score,note) VALUES(?,?,?,?,?)`)
+		assert.NoError(t, err)
+
+		pkt, err := stmt.Exec("bob", int8(2), true, 3.7, nil)
+		assert.NoError(t, err)
+		assert.True(t, conn.valid)
+		assert.Equal(t, pkt.Header, mysqlproto.OK_PACKET)
+		assert.Equal(t, pkt.AffectedRows, uint64(1))
+		assert.Equal(t, pkt.LastInsertID, uint64(1))
+
+		rows, err := conn.Query("SELECT firstname, cars, married, score, note FROM people")
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		assert.Equal(t, rows.String(), "bob")
+		assert.Equal(t, rows.Int(), 2)
+		assert.Equal(t, rows.Bool(), true)
+		assert.Equal(t, rows.Float64(), 3.7)
+		_, null := rows.NullString()
+		assert.True(t, null)
+		assert.False(t, rows.Next())
+	})
+}
+
+func TestStmtSelectDateTime(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		stmt, err := conn.Prepare(`SELECT CAST(? AS DATETIME), CAST(? AS DATE)`)
+		assert.NoError(t, err)
+
+		date := time.Date(2017, time.March, 4, 15, 6, 7, 0, time.UTC)
+		rows, err := stmt.Query(date, date)
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		assert.Equal(t, rows.String(), "2017-03-04 15:06:07")
+		assert.Equal(t, rows.String(), "2017-03-04")
+		assert.False(t, rows.Next())
+	})
+}
+
+func TestStmtPrepareError(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		_, err := conn.Prepare("SELECT * FROM unknown_table WHERE id = ?")
+		assert.True(t, conn.valid)
+		var pkt mysqlproto.ERRPacket
+		ok := errors.As(err, &pkt)
+		assert.True(t, ok)
+		assert.Equal(t, pkt.ErrorCode, mysqlproto.ER_NO_SUCH_TABLE)
+	})
+}
+
+func TestStmtWrongNumberOfArguments(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		stmt, err := conn.Prepare("SELECT id FROM people WHERE id = ?")
+		assert.NoError(t, err)
+		_, err = stmt.Query(1, 2)
+		assert.EqualError(t, err, "mysqldriver: statement expects 1 arguments, got 2")
+		assert.True(t, conn.valid)
+	})
+}
+
+func TestStmtClosed(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		stmt, err := conn.Prepare("SELECT id FROM people WHERE id = ?")
+		assert.NoError(t, err)
+		assert.NoError(t, stmt.Close())
+		assert.NoError(t, stmt.Close())
+		_, err = stmt.Query(1)
+		assert.Equal(t, err, ErrClosedStmt)
+		_, err = stmt.Exec(1)
+		assert.Equal(t, err, ErrClosedStmt)
+	})
+}
+
+func TestStmtInvalidatedWhenConnIsDiscarded(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+
+	stmt, err := conn.Prepare("SELECT ?")
+	assert.NoError(t, err)
+
+	conn.valid = false
+	assert.NoError(t, db.PutConn(conn))
+	_, err = stmt.Query(1)
+	assert.Equal(t, err, ErrClosedStmt)
+	assert.NoError(t, stmt.Close())
+}
+
+func TestStmtOfBrokenConn(t *testing.T) {
+	db, conn, server := multiResultsConn(t, &fakeServer{replies: [][][]byte{prepareOK(1)}})
+	defer server.close()
+	defer db.Close()
+
+	stmt, err := conn.Prepare("SELECT ?")
+	assert.NoError(t, err)
+	conn.valid = false
+	_, err = stmt.Exec(1)
+	assert.Equal(t, err, ErrClosedStmt)
+	assert.Equal(t, server.receivedCommands(), []byte{comStmtPrepare})
+}
@@ -86,3 +86,7 @@ func parseBool(str []byte) (bool, error) {
 func syntaxError(fn, str string) *strconv.NumError {
 	return &strconv.NumError{fn, str, strconv.ErrSyntax}
 }
+
+func rangeError(fn, str string) *strconv.NumError {
+	return &strconv.NumError{fn, str, strconv.ErrRange}
+}
@@ -0,0 +1,67 @@
+package mysqldriver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+)
+
+var (
+	tlsConfigsMu sync.RWMutex
+	tlsConfigs   = make(map[string]*tls.Config)
+)
+
+// RegisterTLSConfig registers TLS config under the name
+// which can be referred by "tls" parameter of data source.
+//  mysqldriver.RegisterTLSConfig("custom", &tls.Config{RootCAs: pool})
+//  db := mysqldriver.NewDB("root@tcp(127.0.0.1:3306)/test?tls=custom", 10, 0)
+// Names "true", "false" and "skip-verify" are reserved.
+func RegisterTLSConfig(name string, config *tls.Config) error {
+	switch name {
+	case "true", "false", "skip-verify":
+		return fmt.Errorf("mysqldriver: TLS config name %q is reserved", name)
+	}
+	tlsConfigsMu.Lock()
+	tlsConfigs[name] = config
+	tlsConfigsMu.Unlock()
+	return nil
+}
+
+// DeregisterTLSConfig removes TLS config registered under the name
+func DeregisterTLSConfig(name string) {
+	tlsConfigsMu.Lock()
+	delete(tlsConfigs, name)
+	tlsConfigsMu.Unlock()
+}
+
+// tlsConfigByName returns TLS config for the value of "tls" parameter
+// of data source. Nil config means that TLS isn't used.
+func tlsConfigByName(name string) (*tls.Config, error) {
+	switch name {
+	case "", "false":
+		return nil, nil
+	case "true":
+		return &tls.Config{}, nil
+	case "skip-verify":
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	tlsConfigsMu.RLock()
+	config, ok := tlsConfigs[name]
+	tlsConfigsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("mysqldriver: unknown TLS config %q", name)
+	}
+	return config, nil
+}
+
+// hostname returns host part of the address
+// used to verify certificate of the server
+func hostname(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
@@ -0,0 +1,117 @@
+package mysqldriver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pubnative/mysqlproto-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// selfSignedCert generates certificate for 127.0.0.1
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mysqldriver test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestNewConnTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	server := startFakeServer(t, &fakeServer{tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}, password: "secret"})
+	defer server.close()
+
+	conn, err := NewConnTLS("root", "secret", "tcp", server.addr(), "test", time.Duration(0), &tls.Config{RootCAs: pool})
+	assert.NoError(t, err)
+	assert.NoError(t, <-server.err)
+	assert.True(t, conn.valid)
+	assert.Equal(t, conn.connectionID, uint32(42))
+	assert.True(t, conn.conn.CapabilityFlags&mysqlproto.CLIENT_SSL != 0)
+	assert.NoError(t, conn.Close())
+}
+
+func TestNewConnTLSUnknownAuthority(t *testing.T) {
+	cert, _ := selfSignedCert(t)
+	server := startFakeServer(t, &fakeServer{tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}, password: "secret"})
+	defer server.close()
+
+	conn, err := NewConnTLS("root", "secret", "tcp", server.addr(), "test", time.Duration(0), &tls.Config{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "certificate signed by unknown authority")
+	assert.False(t, conn.valid)
+}
+
+func TestNewConnTLSNotSupported(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{password: "secret"})
+	defer server.close()
+
+	conn, err := NewConnTLS("root", "secret", "tcp", server.addr(), "test", time.Duration(0), &tls.Config{})
+	assert.Equal(t, err, errNoTLS)
+	assert.False(t, conn.valid)
+}
+
+func TestDBTLSParameter(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	server := startFakeServer(t, &fakeServer{tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}})
+	defer server.close()
+
+	assert.NoError(t, RegisterTLSConfig("fake", &tls.Config{RootCAs: pool}))
+	defer DeregisterTLSConfig("fake")
+
+	db := NewDB("root@tcp("+server.addr()+")/test?tls=fake", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.True(t, conn.conn.CapabilityFlags&mysqlproto.CLIENT_SSL != 0)
+	db.PutConn(conn)
+	db.Close()
+}
+
+func TestDBTLSParameterSkipVerify(t *testing.T) {
+	cert, _ := selfSignedCert(t)
+	server := startFakeServer(t, &fakeServer{tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}}})
+	defer server.close()
+
+	db := NewDB("root@tcp("+server.addr()+")/test?tls=skip-verify", 1, time.Duration(0))
+	conn, err := db.GetConn()
+	assert.NoError(t, err)
+	assert.True(t, conn.conn.CapabilityFlags&mysqlproto.CLIENT_SSL != 0)
+	db.PutConn(conn)
+	db.Close()
+}
+
+func TestDBTLSParameterUnknown(t *testing.T) {
+	db := NewDB("root@tcp(127.0.0.1:3306)/test?tls=unknown", 1, time.Duration(0))
+	_, err := db.GetConn()
+	assert.EqualError(t, err, `mysqldriver: unknown TLS config "unknown"`)
+}
+
+func TestRegisterTLSConfigReservedName(t *testing.T) {
+	assert.EqualError(t, RegisterTLSConfig("true", &tls.Config{}), `mysqldriver: TLS config name "true" is reserved`)
+}
@@ -0,0 +1,210 @@
+package mysqldriver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pubnative/mysqlproto-go"
+)
+
+// ErrTxDone is returned by any operation performed on a transaction
+// which has already been committed or rolled back
+var ErrTxDone = errors.New("mysqldriver: transaction has already been committed or rolled back")
+
+// IsolationLevel is the transaction isolation level
+type IsolationLevel int
+
+const (
+	IsolationDefault IsolationLevel = iota // server's default isolation level
+	IsolationReadUncommitted
+	IsolationReadCommitted
+	IsolationRepeatableRead
+	IsolationSerializable
+)
+
+var isolationLevels = map[IsolationLevel]string{
+	IsolationReadUncommitted: "READ UNCOMMITTED",
+	IsolationReadCommitted:   "READ COMMITTED",
+	IsolationRepeatableRead:  "REPEATABLE READ",
+	IsolationSerializable:    "SERIALIZABLE",
+}
+
+// TxOptions holds the transaction options
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+}
+
+// Tx represents a transaction started on the connection.
+// Transaction must be finished with either Commit or Rollback.
+type Tx struct {
+	conn *Conn
+	done bool
+}
+
+// Begin starts a transaction with the given options.
+//  tx, err := conn.Begin(mysqldriver.TxOptions{})
+//  if err != nil {
+//  	// handle error
+//  }
+//  if _, err := tx.Exec("UPDATE dogs SET age = age + 1"); err != nil {
+//  	tx.Rollback()
+//  	// handle error
+//  }
+//  err = tx.Commit()
+func (c *Conn) Begin(opts TxOptions) (*Tx, error) {
+	return c.BeginContext(context.Background(), opts)
+}
+
+// BeginContext is the same as func (*Conn) Begin, but statements
+// starting the transaction are bound to the context
+// (see func (*Conn) ExecContext)
+func (c *Conn) BeginContext(ctx context.Context, opts TxOptions) (*Tx, error) {
+	if opts.Isolation != IsolationDefault {
+		level, ok := isolationLevels[opts.Isolation]
+		if !ok {
+			return nil, errors.New("mysqldriver: unknown isolation level")
+		}
+		// applies to the next transaction only
+		if _, err := c.ExecContext(ctx, "SET TRANSACTION ISOLATION LEVEL "+level); err != nil {
+			return nil, err
+		}
+	}
+
+	sql := "START TRANSACTION"
+	if opts.ReadOnly {
+		sql += " READ ONLY"
+	}
+	if _, err := c.ExecContext(ctx, sql); err != nil {
+		return nil, err
+	}
+
+	return &Tx{conn: c}, nil
+}
+
+// Conn returns the connection of the transaction
+func (tx *Tx) Conn() *Conn {
+	return tx.conn
+}
+
+// Query performs SELECT query within the transaction (see func (*Conn) Query)
+func (tx *Tx) Query(sql string) (*Rows, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.Query(sql)
+}
+
+// Exec performs the query within the transaction (see func (*Conn) Exec)
+func (tx *Tx) Exec(sql string) (mysqlproto.OKPacket, error) {
+	if tx.done {
+		return mysqlproto.OKPacket{}, ErrTxDone
+	}
+	return tx.conn.Exec(sql)
+}
+
+// QueryArgs performs SELECT query with arguments within
+// the transaction (see func (*Conn) QueryArgs)
+func (tx *Tx) QueryArgs(sql string, args ...interface{}) (*Rows, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	return tx.conn.QueryArgs(sql, args...)
+}
+
+// ExecArgs performs the query with arguments within
+// the transaction (see func (*Conn) ExecArgs)
+func (tx *Tx) ExecArgs(sql string, args ...interface{}) (mysqlproto.OKPacket, error) {
+	if tx.done {
+		return mysqlproto.OKPacket{}, ErrTxDone
+	}
+	return tx.conn.ExecArgs(sql, args...)
+}
+
+// Commit commits the transaction
+func (tx *Tx) Commit() error {
+	return tx.finish("COMMIT")
+}
+
+// Rollback aborts the transaction
+func (tx *Tx) Rollback() error {
+	return tx.finish("ROLLBACK")
+}
+
+// Savepoint sets a named savepoint within the transaction
+func (tx *Tx) Savepoint(name string) error {
+	_, err := tx.Exec("SAVEPOINT " + quoteIdentifier(name))
+	return err
+}
+
+// RollbackTo rolls back the transaction to the named savepoint
+// without terminating the transaction
+func (tx *Tx) RollbackTo(name string) error {
+	_, err := tx.Exec("ROLLBACK TO SAVEPOINT " + quoteIdentifier(name))
+	return err
+}
+
+// ReleaseSavepoint removes the named savepoint
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	_, err := tx.Exec("RELEASE SAVEPOINT " + quoteIdentifier(name))
+	return err
+}
+
+func (tx *Tx) finish(sql string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	_, err := tx.conn.Exec(sql)
+	return err
+}
+
+// inTransaction reports whether server marked the connection
+// as having an open transaction
+func (c *Conn) inTransaction() bool {
+	return c.status&serverStatusInTrans != 0
+}
+
+// WithTx runs the function within a transaction on a connection
+// from the pool. Transaction is committed when the function returns
+// nil error and rolled back otherwise, including the case of panic.
+//  err := db.WithTx(func(tx *mysqldriver.Tx) error {
+//  	_, err := tx.Exec("UPDATE accounts SET amount = amount - 10 WHERE id = 1")
+//  	if err != nil {
+//  		return err
+//  	}
+//  	_, err = tx.Exec("UPDATE accounts SET amount = amount + 10 WHERE id = 2")
+//  	return err
+//  })
+func (db *DB) WithTx(fn func(tx *Tx) error) error {
+	conn, err := db.GetConn()
+	if err != nil {
+		return err
+	}
+	// PutConn rolls back the transaction if it's still open
+	defer db.PutConn(conn)
+
+	tx, err := conn.Begin(TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// quoteIdentifier quotes the name of savepoint, table, etc.
+func quoteIdentifier(name string) string {
+	quoted := make([]byte, 0, len(name)+2)
+	quoted = append(quoted, '`')
+	for i := 0; i < len(name); i++ {
+		if name[i] == '`' {
+			quoted = append(quoted, '`')
+		}
+		quoted = append(quoted, name[i])
+	}
+	return string(append(quoted, '`'))
+}
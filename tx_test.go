@@ -0,0 +1,127 @@
+package mysqldriver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxCommit(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		tx, err := conn.Begin(TxOptions{})
+		assert.NoError(t, err)
+		assert.True(t, conn.inTransaction())
+
+		_, err = tx.Exec(`INSERT INTO people(firstname) VALUES("bob")`)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		assert.False(t, conn.inTransaction())
+
+		assert.Equal(t, tx.Commit(), ErrTxDone)
+		assert.Equal(t, tx.Rollback(), ErrTxDone)
+		_, err = tx.Exec(`INSERT INTO people(firstname) VALUES("ben")`)
+		assert.Equal(t, err, ErrTxDone)
+
+		assert.Equal(t, countPeople(t, conn), 1)
+	})
+}
+
+func TestTxRollback(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		tx, err := conn.Begin(TxOptions{Isolation: IsolationSerializable})
+		assert.NoError(t, err)
+		_, err = tx.ExecArgs(`INSERT INTO people(firstname) VALUES(?)`, "bob")
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Rollback())
+		assert.False(t, conn.inTransaction())
+
+		assert.Equal(t, countPeople(t, conn), 0)
+	})
+}
+
+func TestTxSavepoint(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		tx, err := conn.Begin(TxOptions{})
+		assert.NoError(t, err)
+		_, err = tx.Exec(`INSERT INTO people(firstname) VALUES("bob")`)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Savepoint("first"))
+		_, err = tx.Exec(`INSERT INTO people(firstname) VALUES("ben")`)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.RollbackTo("first"))
+		assert.NoError(t, tx.ReleaseSavepoint("first"))
+		assert.NoError(t, tx.Commit())
+
+		assert.Equal(t, countPeople(t, conn), 1)
+	})
+}
+
+func TestTxReadOnly(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		tx, err := conn.Begin(TxOptions{ReadOnly: true})
+		assert.NoError(t, err)
+		_, err = tx.Exec(`INSERT INTO people(firstname) VALUES("bob")`)
+		assert.Error(t, err)
+		assert.NoError(t, tx.Rollback())
+	})
+}
+
+func TestDBWithTx(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		db := NewDB("root@tcp(127.0.0.1:3306)/test", 1, time.Duration(0))
+		defer db.Close()
+
+		err := db.WithTx(func(tx *Tx) error {
+			_, err := tx.Exec(`INSERT INTO people(firstname) VALUES("bob")`)
+			return err
+		})
+		assert.NoError(t, err)
+
+		errFailed := errors.New("failed")
+		err = db.WithTx(func(tx *Tx) error {
+			if _, err := tx.Exec(`INSERT INTO people(firstname) VALUES("ben")`); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		assert.Equal(t, err, errFailed)
+
+		assert.Equal(t, countPeople(t, conn), 1)
+	})
+}
+
+func TestDBPutConnRollsBackOpenTransaction(t *testing.T) {
+	setup(t, func(conn *Conn) {
+		db := NewDB("root@tcp(127.0.0.1:3306)/test", 1, time.Duration(0))
+		defer db.Close()
+
+		txConn, err := db.GetConn()
+		assert.NoError(t, err)
+		_, err = txConn.Begin(TxOptions{})
+		assert.NoError(t, err)
+		_, err = txConn.Exec(`INSERT INTO people(firstname) VALUES("bob")`)
+		assert.NoError(t, err)
+
+		assert.NoError(t, db.PutConn(txConn))
+		assert.False(t, txConn.inTransaction())
+		assert.Len(t, db.conns, 1)
+
+		assert.Equal(t, countPeople(t, conn), 0)
+	})
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, quoteIdentifier("point"), "`point`")
+	assert.Equal(t, quoteIdentifier("a`b"), "`a``b`")
+}
+
+func countPeople(t *testing.T, conn *Conn) int {
+	rows, err := conn.Query("SELECT COUNT(*) FROM people")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	count := rows.Int()
+	assert.False(t, rows.Next())
+	return count
+}